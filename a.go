@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net/netip"
 )
 
@@ -36,3 +37,17 @@ func (ip *IPv4) PreBuild(_ *Record, _ *Domains) (int, error) {
 
 // TransformName satisfies the interface
 func (*IPv4) TransformName(name string) string { return name }
+
+// parseText implements presentation format parsing of A records
+func (ip *IPv4) parseText(t *rdataText) error {
+	s, err := t.next("address")
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil || !addr.Is4() {
+		return fmt.Errorf("invalid IPv4 address %q", s)
+	}
+	ip.Addr = addr
+	return nil
+}
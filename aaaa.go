@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"net/netip"
 )
 
@@ -36,3 +37,17 @@ func (ip *IPv6) PreBuild(_ *Record, _ *Domains) (int, error) {
 
 // TransformName satisfies the interface
 func (*IPv6) TransformName(name string) string { return name }
+
+// parseText implements presentation format parsing of AAAA records
+func (ip *IPv6) parseText(t *rdataText) error {
+	s, err := t.next("address")
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil || !addr.Is6() {
+		return fmt.Errorf("invalid IPv6 address %q", s)
+	}
+	ip.Addr = addr
+	return nil
+}
@@ -0,0 +1,223 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default settings of a Client
+const (
+	DefaultClientTimeout = 2 * time.Second
+	DefaultClientRetries = 2
+)
+
+// maxMessageSize is the largest DNS message, limited by the TCP length prefix
+const maxMessageSize = 65535
+
+// ErrNoResponse is returned when a server did not answer any attempt
+var ErrNoResponse = errors.New("dns: no response from server")
+
+// Client sends queries to DNS servers. The zero value is ready to use.
+type Client struct {
+	// Timeout of each attempt, defaults to DefaultClientTimeout
+	Timeout time.Duration
+
+	// Retries is the number of UDP attempts after the first one, defaults to
+	// DefaultClientRetries. Set to a negative value for no retries.
+	Retries int
+
+	// Dialer used for the connections to servers, allowing a local address
+	// to be set
+	Dialer net.Dialer
+
+	// Cookies sends DNS cookies (RFC 7873) with each query, with a random
+	// client cookie for each server and the last server cookie learned from
+	// it. Responses with another client cookie are ignored, and a BADCOOKIE
+	// response is retried once with the new server cookie before falling back
+	// to TCP.
+	Cookies bool
+
+	mu      sync.Mutex
+	cookies map[string]*EDNSCookie
+}
+
+// Exchange sends m to server over UDP and returns the response. Replies that
+// do not match the ID and question of m are ignored, attempts time out and are
+// retried, and a truncated response makes the query be repeated over TCP. The
+// server is a host with an optional port, which defaults to 53. A random ID is
+// assigned to m if it has none, and the cookie option is set if Cookies is
+// enabled.
+func (c *Client) Exchange(ctx context.Context, m *Message, server string) (*Message, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	if m.ID == 0 {
+		var id [2]byte
+		rand.Read(id[:])
+		m.ID = binary.BigEndian.Uint16(id[:])
+	}
+	for attempt := 0; ; attempt++ {
+		if c.Cookies {
+			m.SetOption(c.clientCookie(server))
+		}
+		query := new(bytes.Buffer)
+		domains := AcquireDomains()
+		err := m.Build(query, domains)
+		domains.Release()
+		if err != nil {
+			return nil, fmt.Errorf("unable to build query: %w", err)
+		}
+
+		resp, err := c.exchangeUDP(ctx, m, query.Bytes(), server)
+		if err != nil {
+			return nil, err
+		}
+		if c.Cookies {
+			c.learnCookie(server, resp)
+		}
+		badCookie := resp.ExtendedRCode() == RCodeBadCookie
+		if resp.TC || (badCookie && attempt > 0) {
+			return c.exchangeTCP(ctx, m, server)
+		}
+		if !c.Cookies || !badCookie {
+			return resp, nil
+		}
+	}
+}
+
+func (c *Client) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return DefaultClientTimeout
+}
+
+func (c *Client) retries() int {
+	switch {
+	case c.Retries < 0:
+		return 0
+	case c.Retries == 0:
+		return DefaultClientRetries
+	}
+	return c.Retries
+}
+
+// deadline returns the deadline of an attempt started now
+func (c *Client) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(c.timeout())
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+func (c *Client) exchangeUDP(ctx context.Context, m *Message, query []byte,
+	server string,
+) (*Message, error) {
+	conn, err := c.Dialer.DialContext(ctx, "udp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	// All attempts use the same socket, so a late reply to an earlier attempt
+	// is still accepted
+	buf := make([]byte, maxMessageSize)
+	for attempt := 0; attempt <= c.retries(); attempt++ {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(c.deadline(ctx))
+		for {
+			n, err := conn.Read(buf)
+			if err := contextErr(ctx); err != nil {
+				return nil, err
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			resp, err := ParseMessage(bytes.NewBuffer(buf[:n]))
+			if err != nil || !isResponseTo(m, resp) || !cookieMatches(m, resp) {
+				continue
+			}
+			return resp, nil
+		}
+	}
+	return nil, fmt.Errorf("%w %s after %d attempts", ErrNoResponse, server, c.retries()+1)
+}
+
+func (c *Client) exchangeTCP(ctx context.Context, m *Message, server string) (*Message, error) {
+	conn, err := c.Dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	conn.SetDeadline(c.deadline(ctx))
+
+	if c.Cookies {
+		m.SetOption(c.clientCookie(server))
+	}
+	if err := WriteMessage(conn, m); err != nil {
+		return nil, err
+	}
+	resp, err := ReadMessage(conn)
+	if err != nil {
+		return nil, errors.Join(contextErr(ctx), err)
+	}
+	if !isResponseTo(m, resp) || !cookieMatches(m, resp) {
+		return nil, errors.New("dns: TCP response does not match query")
+	}
+	if c.Cookies {
+		c.learnCookie(server, resp)
+	}
+	return resp, nil
+}
+
+// contextErr returns the error of ctx, including when its deadline has passed
+// but the context is not yet marked as done
+func contextErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d, ok := ctx.Deadline(); ok && !time.Now().Before(d) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// isResponseTo reports whether resp is a response to query, matching the ID
+// and question. Servers may leave out the question in error responses.
+func isResponseTo(query, resp *Message) bool {
+	if !resp.QR || resp.ID != query.ID {
+		return false
+	}
+	if len(resp.Questions) == 0 && resp.RCode != RCodeNoError {
+		return true
+	}
+	if len(resp.Questions) != len(query.Questions) {
+		return false
+	}
+	for i, q := range query.Questions {
+		r := resp.Questions[i]
+		if r.Type != q.Type || r.Class != q.Class || !EqualNames(r.Domain, q.Domain) {
+			return false
+		}
+	}
+	return true
+}
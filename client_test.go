@@ -0,0 +1,198 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// testUDPServer answers each query with the replies returned by handle
+func testUDPServer(t *testing.T, handle func(n int, q *Message) []*Message) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, maxMessageSize)
+		for n := 0; ; n++ {
+			size, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			q, err := ParseMessage(bytes.NewBuffer(buf[:size]))
+			if err != nil {
+				continue
+			}
+			for _, reply := range handle(n, q) {
+				out := new(bytes.Buffer)
+				reply.Build(out, NewDomains())
+				conn.WriteToUDP(out.Bytes(), addr)
+			}
+		}
+	}()
+	return conn
+}
+
+func testAnswer(q *Message, addr string) *Message {
+	reply := ReplyTo(q)
+	reply.Answers = []Record{{Name: q.Questions[0].Domain, Type: A, Class: uint16(IN),
+		TTL: 60, Data: &IPv4{netip.MustParseAddr(addr)}}}
+	return reply
+}
+
+func testQuery() *Message {
+	return &Message{RD: true, Questions: []Question{{Domain: "example.com", Type: A, Class: IN}}}
+}
+
+func TestClient_Exchange(t *testing.T) {
+	tests := []struct {
+		name     string
+		handle   func(n int, q *Message) []*Message
+		want     string
+		wantErr  error
+		attempts int
+	}{
+		{
+			name: "Simple answer",
+			handle: func(_ int, q *Message) []*Message {
+				return []*Message{testAnswer(q, "192.0.2.1")}
+			},
+			want: "192.0.2.1",
+		},
+		{
+			name: "Retry after lost query",
+			handle: func(n int, q *Message) []*Message {
+				if n == 0 {
+					return nil
+				}
+				return []*Message{testAnswer(q, "192.0.2.2")}
+			},
+			want: "192.0.2.2",
+		},
+		{
+			name: "Ignore mismatched replies",
+			handle: func(_ int, q *Message) []*Message {
+				wrongID := testAnswer(q, "198.51.100.1")
+				wrongID.ID++
+				wrongQuestion := testAnswer(q, "198.51.100.2")
+				wrongQuestion.Questions = []Question{{Domain: "example.org", Type: A, Class: IN}}
+				return []*Message{wrongID, wrongQuestion, testAnswer(q, "192.0.2.3")}
+			},
+			want: "192.0.2.3",
+		},
+		{
+			name:    "No response",
+			handle:  func(int, *Message) []*Message { return nil },
+			wantErr: ErrNoResponse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := testUDPServer(t, tt.handle)
+			c := &Client{Timeout: 100 * time.Millisecond, Retries: 1}
+			resp, err := c.Exchange(context.Background(), testQuery(), srv.LocalAddr().String())
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Client.Exchange() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Client.Exchange() error = %v", err)
+			}
+			if got := resp.Answers[0].Data.(*IPv4).String(); got != tt.want {
+				t.Errorf("Client.Exchange() answer = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_ExchangeTCPFallback(t *testing.T) {
+	var srv *net.UDPConn
+	var ln net.Listener
+	// Find a port free for both UDP and TCP
+	for i := 0; ln == nil && i < 10; i++ {
+		srv = testUDPServer(t, func(_ int, q *Message) []*Message {
+			reply := ReplyTo(q)
+			reply.TC = true
+			return []*Message{reply}
+		})
+		var err error
+		ln, err = net.Listen("tcp", srv.LocalAddr().String())
+		if err != nil {
+			srv.Close()
+		}
+	}
+	if ln == nil {
+		t.Fatal("unable to listen on a shared UDP and TCP port")
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		q, err := ReadMessage(conn)
+		if err != nil {
+			return
+		}
+		WriteMessage(conn, testAnswer(q, "192.0.2.53"))
+	}()
+
+	c := &Client{Timeout: time.Second}
+	resp, err := c.Exchange(context.Background(), testQuery(), srv.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Client.Exchange() error = %v", err)
+	}
+	if resp.TC || len(resp.Answers) != 1 {
+		t.Errorf("Client.Exchange() = %v, want full TCP response", resp)
+	}
+}
+
+func TestClient_ExchangeContext(t *testing.T) {
+	srv := testUDPServer(t, func(int, *Message) []*Message { return nil })
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c := &Client{Timeout: time.Second, Retries: 3}
+	start := time.Now()
+	_, err := c.Exchange(ctx, testQuery(), srv.LocalAddr().String())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Client.Exchange() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Client.Exchange() took %v, want it to stop at the context deadline", elapsed)
+	}
+}
+
+func TestIsResponseTo(t *testing.T) {
+	q := testQuery()
+	q.ID = 1
+	tests := []struct {
+		name string
+		resp *Message
+		want bool
+	}{
+		{name: "Matching", resp: ReplyTo(q), want: true},
+		{name: "Not a response", resp: &Message{ID: 1, Questions: q.Questions}, want: false},
+		{name: "Case insensitive", resp: &Message{ID: 1, QR: true,
+			Questions: []Question{{Domain: "EXAMPLE.com", Type: A, Class: IN}}}, want: true},
+		{name: "Wrong type", resp: &Message{ID: 1, QR: true,
+			Questions: []Question{{Domain: "example.com", Type: AAAA, Class: IN}}}, want: false},
+		{name: "Error without question", resp: &Message{ID: 1, QR: true, RCode: RCodeFormErr}, want: true},
+		{name: "Success without question", resp: &Message{ID: 1, QR: true}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isResponseTo(q, tt.resp); got != tt.want {
+				t.Errorf("isResponseTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
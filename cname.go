@@ -2,7 +2,7 @@ package dns
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
 )
 
 // CName implements interface RData
@@ -15,7 +15,7 @@ type CName struct {
 func (n *CName) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
 	name, err := ParseName(buf, ptr, domains)
 	if err != nil {
-		return errors.New("unable to parse CNAME: " + err.Error())
+		return fmt.Errorf("unable to parse CNAME: %w", err)
 	}
 	n.Name = name
 	return nil
@@ -30,9 +30,24 @@ func (n *CName) Build(buf *bytes.Buffer, domains *Domains) error {
 
 // PreBuild implements CNAME pre building for interface RData
 func (n *CName) PreBuild(_ *Record, domains *Domains) (int, error) {
-	n.bytes = BuildName(n.Name, domains)
+	var err error
+	if n.bytes, err = buildName(n.Name, domains); err != nil {
+		return 0, err
+	}
 	return len(n.bytes), nil
 }
 
 // TransformName satisfies the interface
 func (*CName) TransformName(name string) string { return name }
+
+// parseText implements presentation format parsing of CNAME records
+func (n *CName) parseText(t *rdataText) error {
+	name, err := t.name("name")
+	n.Name = name
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (n *CName) String() string { return n.format(FormatOptions{}) }
+
+func (n *CName) format(opts FormatOptions) string { return opts.name(n.Name) }
@@ -0,0 +1,273 @@
+package dns
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"math/bits"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// Server cookies in the interoperable format of RFC 9018
+const (
+	cookieVersion       = 1
+	serverCookieLength  = 16
+	cookieMaxAge        = time.Hour
+	cookieMaxSkew       = 5 * time.Minute
+	cookieRefreshPeriod = 30 * time.Minute
+)
+
+// CookieSecret creates and verifies server cookies (RFC 7873) in the
+// interoperable format of RFC 9018, so servers of a service sharing the secret
+// accept the cookies of each other. The secret is changed with Rotate, and
+// cookies created with the previous secret are accepted until the next
+// rotation.
+type CookieSecret struct {
+	mu       sync.RWMutex
+	current  [16]byte
+	previous *[16]byte
+}
+
+// NewCookieSecret returns a CookieSecret creating cookies with secret, which
+// should be random and shared only by the servers of a service
+func NewCookieSecret(secret [16]byte) *CookieSecret {
+	return &CookieSecret{current: secret}
+}
+
+// Rotate makes secret the secret for new cookies, while cookies created with
+// the current secret are still accepted. RFC 9018 section 5 recommends that
+// all servers first learn the new secret, before it is used for new cookies.
+func (s *CookieSecret) Rotate(secret [16]byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous := s.current
+	s.current, s.previous = secret, &previous
+}
+
+// ServerCookie returns a new server cookie for the client cookie of a client
+// at clientIP
+func (s *CookieSecret) ServerCookie(client [8]byte, clientIP netip.Addr) []byte {
+	return s.serverCookie(client, clientIP, time.Now())
+}
+
+// Verify reports whether cookie holds a server cookie created for the client
+// cookie and clientIP in the last hour
+func (s *CookieSecret) Verify(cookie *EDNSCookie, clientIP netip.Addr) bool {
+	return s.verify(cookie, clientIP, time.Now())
+}
+
+func (s *CookieSecret) serverCookie(client [8]byte, clientIP netip.Addr, now time.Time) []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cookie := make([]byte, 8, serverCookieLength)
+	cookie[0] = cookieVersion
+	binary.BigEndian.PutUint32(cookie[4:], uint32(now.Unix()))
+	return binary.LittleEndian.AppendUint64(cookie, cookieHash(s.current, client, cookie,
+		clientIP))
+}
+
+func (s *CookieSecret) verify(cookie *EDNSCookie, clientIP netip.Addr, now time.Time) bool {
+	server := cookie.Server
+	if len(server) != serverCookieLength || server[0] != cookieVersion {
+		return false
+	}
+	// Timestamps use serial number arithmetic (RFC 1982)
+	age := time.Duration(int32(uint32(now.Unix())-binary.BigEndian.Uint32(server[4:]))) *
+		time.Second
+	if age > cookieMaxAge || age < -cookieMaxSkew {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hash := binary.LittleEndian.Uint64(server[8:])
+	if cookieHash(s.current, cookie.Client, server[:8], clientIP) == hash {
+		return true
+	}
+	return s.previous != nil &&
+		cookieHash(*s.previous, cookie.Client, server[:8], clientIP) == hash
+}
+
+// reply returns the cookie for a response to a query carrying cookie, and
+// whether the server cookie of the query is valid. Valid server cookies are
+// kept unless they are due to be refreshed.
+func (s *CookieSecret) reply(cookie *EDNSCookie, clientIP netip.Addr, now time.Time,
+) (*EDNSCookie, bool) {
+	reply := &EDNSCookie{Client: cookie.Client}
+	if !s.verify(cookie, clientIP, now) {
+		reply.Server = s.serverCookie(cookie.Client, clientIP, now)
+		return reply, false
+	}
+	created := time.Unix(int64(binary.BigEndian.Uint32(cookie.Server[4:])), 0)
+	if now.Sub(created) > cookieRefreshPeriod {
+		reply.Server = s.serverCookie(cookie.Client, clientIP, now)
+	} else {
+		reply.Server = append([]byte(nil), cookie.Server...)
+	}
+	return reply, true
+}
+
+// cookieHash returns the SipHash-2-4 of the client cookie, the version,
+// reserved and timestamp fields of the server cookie in header, and the
+// client address (RFC 9018 section 4.4)
+func cookieHash(secret [16]byte, client [8]byte, header []byte, clientIP netip.Addr) uint64 {
+	msg := make([]byte, 0, 8+len(header)+16)
+	msg = append(msg, client[:]...)
+	msg = append(msg, header...)
+	msg = append(msg, clientIP.Unmap().AsSlice()...)
+	return sipHash24(secret, msg)
+}
+
+// sipHash24 returns the SipHash-2-4 of msg with key
+func sipHash24(key [16]byte, msg []byte) uint64 {
+	k0 := binary.LittleEndian.Uint64(key[:8])
+	k1 := binary.LittleEndian.Uint64(key[8:])
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+	round := func() {
+		v0 += v1
+		v1 = bits.RotateLeft64(v1, 13) ^ v0
+		v0 = bits.RotateLeft64(v0, 32)
+		v2 += v3
+		v3 = bits.RotateLeft64(v3, 16) ^ v2
+		v0 += v3
+		v3 = bits.RotateLeft64(v3, 21) ^ v0
+		v2 += v1
+		v1 = bits.RotateLeft64(v1, 17) ^ v2
+		v2 = bits.RotateLeft64(v2, 32)
+	}
+	compress := func(m uint64) {
+		v3 ^= m
+		round()
+		round()
+		v0 ^= m
+	}
+
+	length := len(msg)
+	for ; len(msg) >= 8; msg = msg[8:] {
+		compress(binary.LittleEndian.Uint64(msg))
+	}
+	var last [8]byte
+	copy(last[:], msg)
+	last[7] = byte(length)
+	compress(binary.LittleEndian.Uint64(last[:]))
+
+	v2 ^= 0xff
+	for i := 0; i < 4; i++ {
+		round()
+	}
+	return v0 ^ v1 ^ v2 ^ v3
+}
+
+// CookieHandler adds DNS cookies (RFC 7873) to the responses of Handler, with
+// server cookies created and verified by Secret. Queries without a cookie are
+// passed on unchanged.
+type CookieHandler struct {
+	Handler Handler
+	Secret  *CookieSecret
+
+	// RequireCookie answers UDP queries with a client cookie but without a
+	// valid server cookie with BADCOOKIE and a new server cookie, instead of
+	// passing them to Handler. Responses to queries from spoofed addresses
+	// are then limited to a small error, while clients retry with the
+	// server cookie (RFC 7873 section 5.2.3).
+	RequireCookie bool
+}
+
+// ServeDNS implements Handler
+func (h *CookieHandler) ServeDNS(w ResponseWriter, r *Message) {
+	cookie := r.cookie()
+	if cookie == nil {
+		h.Handler.ServeDNS(w, r)
+		return
+	}
+	reply, valid := h.Secret.reply(cookie, addrIP(w.RemoteAddr()), time.Now())
+	if _, udp := w.RemoteAddr().(*net.UDPAddr); !valid && udp && h.RequireCookie {
+		m := ReplyTo(r)
+		m.SetOption(reply)
+		m.SetExtendedRCode(RCodeBadCookie)
+		w.WriteMessage(m)
+		return
+	}
+	h.Handler.ServeDNS(&cookieResponseWriter{ResponseWriter: w, cookie: reply}, r)
+}
+
+// cookieResponseWriter sets the cookie option of the messages it writes
+type cookieResponseWriter struct {
+	ResponseWriter
+	cookie *EDNSCookie
+}
+
+func (w *cookieResponseWriter) WriteMessage(m *Message) error {
+	m.SetOption(w.cookie)
+	return w.ResponseWriter.WriteMessage(m)
+}
+
+// addrIP returns the IP address of a UDP or TCP address
+func addrIP(addr net.Addr) netip.Addr {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.AddrPort().Addr()
+	case *net.TCPAddr:
+		return a.AddrPort().Addr()
+	}
+	return netip.Addr{}
+}
+
+// cookie returns the cookie option of m, or nil if it has none
+func (m *Message) cookie() *EDNSCookie {
+	opt := m.Opt()
+	if opt == nil {
+		return nil
+	}
+	if o, ok := opt.Option(OptionCodeCookie); ok {
+		if c, ok := o.(*EDNSCookie); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// clientCookie returns the cookie for a query to server. The client cookie is
+// random for each server, and the server cookie is the last one learned from
+// the server.
+func (c *Client) clientCookie(server string) *EDNSCookie {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cookies == nil {
+		c.cookies = map[string]*EDNSCookie{}
+	}
+	cookie, ok := c.cookies[server]
+	if !ok {
+		cookie = &EDNSCookie{}
+		rand.Read(cookie.Client[:])
+		c.cookies[server] = cookie
+	}
+	return &EDNSCookie{Client: cookie.Client, Server: bytes.Clone(cookie.Server)}
+}
+
+// learnCookie stores the server cookie of resp from server
+func (c *Client) learnCookie(server string, resp *Message) {
+	cookie := resp.cookie()
+	if cookie == nil || len(cookie.Server) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if known, ok := c.cookies[server]; ok && known.Client == cookie.Client {
+		known.Server = bytes.Clone(cookie.Server)
+	}
+}
+
+// cookieMatches reports whether the client cookie of resp, if any, is the one
+// sent in query. Responses with another client cookie are forged (RFC 7873
+// section 5.3).
+func cookieMatches(query, resp *Message) bool {
+	sent, got := query.cookie(), resp.cookie()
+	return sent == nil || got == nil || sent.Client == got.Client
+}
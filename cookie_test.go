@@ -0,0 +1,278 @@
+package dns
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSipHash24(t *testing.T) {
+	// Test vector from appendix A of the SipHash paper
+	var key [16]byte
+	msg := make([]byte, 15)
+	for i := range key {
+		key[i] = byte(i)
+		if i < len(msg) {
+			msg[i] = byte(i)
+		}
+	}
+	if got, want := sipHash24(key, msg), uint64(0xa129ca6149be45e5); got != want {
+		t.Errorf("sipHash24() = %x, want %x", got, want)
+	}
+}
+
+func testCookieSecret(t *testing.T, s string) [16]byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 16 {
+		t.Fatalf("invalid secret %q", s)
+	}
+	return [16]byte(b)
+}
+
+func testClientCookie(t *testing.T, s string) [8]byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 8 {
+		t.Fatalf("invalid client cookie %q", s)
+	}
+	return [8]byte(b)
+}
+
+func TestCookieSecret_ServerCookie(t *testing.T) {
+	// Test vectors from RFC 9018 appendix A
+	tests := []struct {
+		name     string
+		secret   string
+		client   string
+		clientIP string
+		time     int64
+		want     string
+	}{
+		{
+			name:     "Learning a new server cookie",
+			secret:   "e5e973e5a6b2a43f48e7dc849e37bfcf",
+			client:   "2464c4abcf10c957",
+			clientIP: "198.51.100.100",
+			time:     1559731985,
+			want:     "010000005cf79f111f8130c3eee29480",
+		},
+		{
+			name:     "Renewed server cookie",
+			secret:   "e5e973e5a6b2a43f48e7dc849e37bfcf",
+			client:   "2464c4abcf10c957",
+			clientIP: "198.51.100.100",
+			time:     1559734385,
+			want:     "010000005cf7a871d4a564a1442aca77",
+		},
+		{
+			name:     "Other resolver",
+			secret:   "e5e973e5a6b2a43f48e7dc849e37bfcf",
+			client:   "fc93fc62807ddb86",
+			clientIP: "203.0.113.203",
+			time:     1559734700,
+			want:     "010000005cf7a9acf73a7810aca2381e",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewCookieSecret(testCookieSecret(t, tt.secret))
+			got := s.serverCookie(testClientCookie(t, tt.client),
+				netip.MustParseAddr(tt.clientIP), time.Unix(tt.time, 0))
+			if hex.EncodeToString(got) != tt.want {
+				t.Errorf("CookieSecret.ServerCookie() = %x, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieSecret_Verify(t *testing.T) {
+	first := testCookieSecret(t, "e5e973e5a6b2a43f48e7dc849e37bfcf")
+	second := testCookieSecret(t, "445536bcd2513298075a5d379663c962")
+	client := testClientCookie(t, "2464c4abcf10c957")
+	clientIP := netip.MustParseAddr("198.51.100.100")
+	created := time.Unix(1559731985, 0)
+	cookie := &EDNSCookie{Client: client,
+		Server: NewCookieSecret(first).serverCookie(client, clientIP, created)}
+
+	rotated := NewCookieSecret(first)
+	rotated.Rotate(second)
+	expired := NewCookieSecret(first)
+	expired.Rotate(second)
+	expired.Rotate([16]byte{3})
+	tests := []struct {
+		name     string
+		secret   *CookieSecret
+		cookie   *EDNSCookie
+		clientIP netip.Addr
+		now      time.Time
+		want     bool
+	}{
+		{name: "Valid", secret: NewCookieSecret(first), cookie: cookie, now: created.Add(time.Minute),
+			want: true},
+		{name: "Clock skew", secret: NewCookieSecret(first), cookie: cookie,
+			now: created.Add(-4 * time.Minute), want: true},
+		{name: "From the future", secret: NewCookieSecret(first), cookie: cookie,
+			now: created.Add(-10 * time.Minute)},
+		{name: "Expired", secret: NewCookieSecret(first), cookie: cookie,
+			now: created.Add(61 * time.Minute)},
+		{name: "Other client address", secret: NewCookieSecret(first), cookie: cookie,
+			clientIP: netip.MustParseAddr("198.51.100.101"), now: created},
+		{name: "Other client cookie", secret: NewCookieSecret(first), now: created,
+			cookie: &EDNSCookie{Client: [8]byte{1}, Server: cookie.Server}},
+		{name: "Previous secret", secret: rotated, cookie: cookie, now: created, want: true},
+		{name: "Rotated out", secret: NewCookieSecret(second), cookie: cookie, now: created},
+		{name: "Rotated out twice", secret: expired, cookie: cookie, now: created},
+		{name: "No server cookie", secret: NewCookieSecret(first), now: created,
+			cookie: &EDNSCookie{Client: client}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := tt.clientIP
+			if !ip.IsValid() {
+				ip = clientIP
+			}
+			if got := tt.secret.verify(tt.cookie, ip, tt.now); got != tt.want {
+				t.Errorf("CookieSecret.Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testAddrWriter is a testResponseWriter with a remote address
+type testAddrWriter struct {
+	testResponseWriter
+	addr net.Addr
+}
+
+func (w *testAddrWriter) RemoteAddr() net.Addr { return w.addr }
+
+func TestCookieHandler(t *testing.T) {
+	secret := NewCookieSecret([16]byte{1, 2, 3})
+	udp := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5353}
+	tcp := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 5353}
+	client := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	valid := &EDNSCookie{Client: client,
+		Server: secret.ServerCookie(client, netip.MustParseAddr("192.0.2.1"))}
+
+	tests := []struct {
+		name          string
+		cookie        *EDNSCookie
+		addr          net.Addr
+		wantBadCookie bool
+		wantServer    []byte
+	}{
+		{name: "No cookie", addr: udp},
+		{name: "Client cookie only", cookie: &EDNSCookie{Client: client}, addr: udp,
+			wantBadCookie: true},
+		{name: "Client cookie only over TCP", cookie: &EDNSCookie{Client: client}, addr: tcp},
+		{name: "Valid server cookie", cookie: valid, addr: udp, wantServer: valid.Server},
+		{name: "Invalid server cookie", addr: udp, wantBadCookie: true,
+			cookie: &EDNSCookie{Client: client, Server: make([]byte, 16)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handled := false
+			h := &CookieHandler{
+				Handler: HandlerFunc(func(w ResponseWriter, r *Message) {
+					handled = true
+					w.WriteMessage(testAnswer(r, "192.0.2.53"))
+				}),
+				Secret:        secret,
+				RequireCookie: true,
+			}
+			q := testQuery()
+			if tt.cookie != nil {
+				q.SetOption(tt.cookie)
+			}
+			w := &testAddrWriter{addr: tt.addr}
+			h.ServeDNS(w, q)
+			if len(w.msgs) != 1 {
+				t.Fatalf("CookieHandler.ServeDNS() wrote %d messages, want 1", len(w.msgs))
+			}
+			resp := w.msgs[0]
+			badCookie := resp.ExtendedRCode() == RCodeBadCookie
+			if handled == tt.wantBadCookie || badCookie != tt.wantBadCookie {
+				t.Errorf("CookieHandler.ServeDNS() handled = %v, BADCOOKIE = %v, want BADCOOKIE %v",
+					handled, badCookie, tt.wantBadCookie)
+			}
+			got := resp.cookie()
+			if tt.cookie == nil {
+				if got != nil {
+					t.Errorf("CookieHandler.ServeDNS() cookie = %v, want none", got)
+				}
+				return
+			}
+			if got == nil || got.Client != client {
+				t.Fatalf("CookieHandler.ServeDNS() cookie = %v, want client cookie %x", got, client)
+			}
+			if !secret.Verify(got, netip.MustParseAddr("192.0.2.1")) {
+				t.Errorf("CookieHandler.ServeDNS() server cookie %x does not verify", got.Server)
+			}
+			if tt.wantServer != nil && string(got.Server) != string(tt.wantServer) {
+				t.Errorf("CookieHandler.ServeDNS() server cookie = %x, want %x", got.Server,
+					tt.wantServer)
+			}
+		})
+	}
+}
+
+func TestClient_ExchangeCookies(t *testing.T) {
+	var handled atomic.Int32
+	h := &CookieHandler{
+		Handler: HandlerFunc(func(w ResponseWriter, r *Message) {
+			handled.Add(1)
+			w.WriteMessage(testAnswer(r, "192.0.2.1"))
+		}),
+		Secret:        NewCookieSecret([16]byte{1, 2, 3}),
+		RequireCookie: true,
+	}
+	_, addr := testServer(t, h)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c := &Client{Timeout: time.Second, Cookies: true}
+
+	// The first query learns the server cookie from a BADCOOKIE response and
+	// is retried with it
+	for i := 1; i <= 2; i++ {
+		resp, err := c.Exchange(ctx, testQuery(), addr)
+		if err != nil {
+			t.Fatalf("Client.Exchange() error = %v", err)
+		}
+		if len(resp.Answers) != 1 || resp.cookie() == nil {
+			t.Errorf("Client.Exchange() = %v, want answer with cookie", resp)
+		}
+		if got := handled.Load(); got != int32(i) {
+			t.Errorf("Client.Exchange() handled %d queries, want %d", got, i)
+		}
+	}
+}
+
+func TestCookieMatches(t *testing.T) {
+	withCookie := func(client byte) *Message {
+		m := testQuery()
+		m.SetOption(&EDNSCookie{Client: [8]byte{client}})
+		return m
+	}
+	tests := []struct {
+		name        string
+		query, resp *Message
+		want        bool
+	}{
+		{name: "Same client cookie", query: withCookie(1), resp: withCookie(1), want: true},
+		{name: "Other client cookie", query: withCookie(1), resp: withCookie(2), want: false},
+		{name: "Server without cookies", query: withCookie(1), resp: testQuery(), want: true},
+		{name: "Query without cookie", query: testQuery(), resp: withCookie(2), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cookieMatches(tt.query, tt.resp); got != tt.want {
+				t.Errorf("cookieMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package dns
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDenialNotProven is returned when NSEC or NSEC3 records do not prove the
+// non-existence of a name or type
+var ErrDenialNotProven = errors.New("dnssec: denial of existence not proven")
+
+// nsec3Encoding is the base32 encoding with extended hex alphabet, without
+// padding, used for NSEC3 owner names
+var nsec3Encoding = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// VerifyNSECNameError checks that nsecs prove that qname does not exist, as
+// described in RFC 4035 section 5.4. The signatures of the NSEC records must be
+// verified separately.
+func VerifyNSECNameError(qname string, nsecs []Record) error {
+	cover, ok := nsecCovering(qname, nsecs)
+	if !ok {
+		return fmt.Errorf("%w: no NSEC covers %s", ErrDenialNotProven, qname)
+	}
+	next := cover.Data.(*Nsec).NextDomain
+	ce := commonAncestor(qname, cover.Name)
+	if other := commonAncestor(qname, next); len(nameLabels(other)) > len(nameLabels(ce)) {
+		ce = other
+	}
+	wildcard := wildcardName(ce)
+	if _, ok := nsecCovering(wildcard, nsecs); !ok {
+		return fmt.Errorf("%w: no NSEC covers wildcard %s", ErrDenialNotProven, wildcard)
+	}
+	return nil
+}
+
+// VerifyNSECNoData checks that nsecs prove that qname has no records of type
+// qtype, either directly, as an empty non-terminal or through a wildcard. The signatures of the NSEC
+// records must be verified separately.
+func VerifyNSECNoData(qname string, qtype Type, nsecs []Record) error {
+	for _, r := range nsecs {
+		n, ok := r.Data.(*Nsec)
+		if !ok || CompareNames(r.Name, qname) != 0 {
+			continue
+		}
+		return checkNoDataTypes(qname, qtype, n.TypeBitMap)
+	}
+
+	// Wildcard no data, the name is covered and the wildcard at the closest
+	// encloser lacks the type
+	cover, ok := nsecCovering(qname, nsecs)
+	if !ok {
+		return fmt.Errorf("%w: no NSEC matches or covers %s", ErrDenialNotProven, qname)
+	}
+	next := cover.Data.(*Nsec).NextDomain
+	// An empty non-terminal has no NSEC of its own, the NSEC covering it has
+	// a name below it as next name (RFC 4035 section 3.1.3.2)
+	if IsSubdomain(next, qname) && CompareNames(next, qname) != 0 {
+		return nil
+	}
+	ce := commonAncestor(qname, cover.Name)
+	if other := commonAncestor(qname, next); len(nameLabels(other)) > len(nameLabels(ce)) {
+		ce = other
+	}
+	wildcard := wildcardName(ce)
+	for _, r := range nsecs {
+		if n, ok := r.Data.(*Nsec); ok && CompareNames(r.Name, wildcard) == 0 {
+			return checkNoDataTypes(wildcard, qtype, n.TypeBitMap)
+		}
+	}
+	return fmt.Errorf("%w: no NSEC matches wildcard %s", ErrDenialNotProven, wildcard)
+}
+
+// nsecCovering returns the NSEC record proving that name does not exist
+func nsecCovering(name string, nsecs []Record) (Record, bool) {
+	for _, r := range nsecs {
+		n, ok := r.Data.(*Nsec)
+		if !ok {
+			continue
+		}
+		owner, next := r.Name, n.NextDomain
+		if !IsSubdomain(name, commonAncestor(owner, next)) {
+			continue
+		}
+		// An NSEC from the parent side of a delegation, or at a DNAME, does
+		// not prove anything about names below it
+		if IsSubdomain(name, owner) && CompareNames(name, owner) != 0 &&
+			((n.HasType(NS) && !n.HasType(SOA)) || n.HasType(DNAME)) {
+			continue
+		}
+		if CompareNames(owner, name) < 0 &&
+			(CompareNames(name, next) < 0 || CompareNames(next, owner) <= 0) {
+			return r, true
+		}
+	}
+	return Record{}, false
+}
+
+// checkNoDataTypes checks that the types of an NSEC or NSEC3 matching qname
+// prove that qtype does not exist
+func checkNoDataTypes(qname string, qtype Type, types []Type) error {
+	switch {
+	case hasType(types, qtype):
+		return fmt.Errorf("%w: %s has type %s", ErrDenialNotProven, qname, RRTypeStrings[qtype])
+	case hasType(types, CNAME):
+		return fmt.Errorf("%w: %s has a CNAME", ErrDenialNotProven, qname)
+	case qtype != DS && hasType(types, NS) && !hasType(types, SOA):
+		return fmt.Errorf("%w: %s is a delegation", ErrDenialNotProven, qname)
+	case qtype == DS && hasType(types, SOA) && qname != "":
+		return fmt.Errorf("%w: proof for DS %s is from the child zone", ErrDenialNotProven, qname)
+	}
+	return nil
+}
+
+// commonAncestor returns the longest name both a and b are equal to or below
+func commonAncestor(a, b string) string {
+	la, lb := nameLabels(a), nameLabels(b)
+	n := 0
+	for n < len(la) && n < len(lb) &&
+		CanonicalName(la[len(la)-1-n]) == CanonicalName(lb[len(lb)-1-n]) {
+		n++
+	}
+	return strings.Join(la[len(la)-n:], ".")
+}
+
+// wildcardName returns the wildcard name directly below name
+func wildcardName(name string) string {
+	if name == "" {
+		return "*"
+	}
+	return "*." + name
+}
+
+// HashName returns the NSEC3 hash of name as described in RFC 5155 section 5
+func HashName(name string, alg uint8, iterations uint16, salt []byte) ([]byte, error) {
+	if alg != Nsec3SHA1 {
+		return nil, fmt.Errorf("unsupported NSEC3 hash algorithm: %d", alg)
+	}
+	h := sha1.New()
+	h.Write([]byte(BuildName(CanonicalName(name), nil)))
+	h.Write(salt)
+	digest := h.Sum(nil)
+	for i := 0; i < int(iterations); i++ {
+		h.Reset()
+		h.Write(digest)
+		h.Write(salt)
+		digest = h.Sum(digest[:0])
+	}
+	return digest, nil
+}
+
+// HashedOwner returns the NSEC3 owner name of name in zone
+func HashedOwner(name, zone string, alg uint8, iterations uint16, salt []byte) (string, error) {
+	digest, err := HashName(name, alg, iterations, salt)
+	if err != nil {
+		return "", err
+	}
+	label := strings.ToLower(nsec3Encoding.EncodeToString(digest))
+	if zone == "" {
+		return label, nil
+	}
+	return label + "." + zone, nil
+}
+
+// nsec3Entry is a parsed NSEC3 record with its decoded owner hash
+type nsec3Entry struct {
+	hash []byte
+	zone string
+	data *Nsec3
+}
+
+// parseNsec3Set returns the usable NSEC3 records of records, which all must use
+// the same zone and parameters
+func parseNsec3Set(records []Record) ([]nsec3Entry, error) {
+	var entries []nsec3Entry
+	for _, r := range records {
+		n, ok := r.Data.(*Nsec3)
+		if !ok {
+			continue
+		}
+		labels := nameLabels(r.Name)
+		if len(labels) == 0 {
+			continue
+		}
+		hash, err := nsec3Encoding.DecodeString(strings.ToUpper(labels[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid NSEC3 owner name %s: %w", r.Name, err)
+		}
+		e := nsec3Entry{
+			hash: hash,
+			zone: CanonicalName(strings.Join(labels[1:], ".")),
+			data: n,
+		}
+		if len(entries) > 0 {
+			first := entries[0]
+			if first.zone != e.zone || first.data.Iterations != n.Iterations ||
+				first.data.HashAlgorithm != n.HashAlgorithm ||
+				!bytes.Equal(first.data.Salt, n.Salt) {
+				return nil, errors.New("NSEC3 records with mixed zones or parameters")
+			}
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no NSEC3 records", ErrDenialNotProven)
+	}
+	return entries, nil
+}
+
+func nsec3Hash(name string, entries []nsec3Entry) []byte {
+	n := entries[0].data
+	hash, _ := HashName(name, n.HashAlgorithm, n.Iterations, n.Salt)
+	return hash
+}
+
+// nsec3Matching returns the NSEC3 record whose owner is the hash of name
+func nsec3Matching(name string, entries []nsec3Entry) (*Nsec3, bool) {
+	hash := nsec3Hash(name, entries)
+	for _, e := range entries {
+		if bytes.Equal(e.hash, hash) {
+			return e.data, true
+		}
+	}
+	return nil, false
+}
+
+// nsec3Covering returns the NSEC3 record whose hash range covers the hash of
+// name
+func nsec3Covering(name string, entries []nsec3Entry) (*Nsec3, bool) {
+	hash := nsec3Hash(name, entries)
+	for _, e := range entries {
+		owner, next := e.hash, e.data.NextHashed
+		afterOwner := bytes.Compare(owner, hash) < 0
+		beforeNext := bytes.Compare(hash, next) < 0
+		if bytes.Compare(next, owner) <= 0 {
+			// The last NSEC3 in the chain wraps around to the first
+			if afterOwner || beforeNext {
+				return e.data, true
+			}
+		} else if afterOwner && beforeNext {
+			return e.data, true
+		}
+	}
+	return nil, false
+}
+
+// nsec3ClosestEncloser performs the closest encloser proof of RFC 5155
+// section 8.3, returning the closest encloser and the NSEC3 covering the next
+// closer name
+func nsec3ClosestEncloser(qname string, entries []nsec3Entry) (string, *Nsec3, error) {
+	zone := entries[0].zone
+	if !IsSubdomain(qname, zone) {
+		return "", nil, fmt.Errorf("%w: %s is not in zone %s", ErrDenialNotProven, qname, zone)
+	}
+	labels := nameLabels(qname)
+	for i := 1; i <= len(labels); i++ {
+		ce := strings.Join(labels[i:], ".")
+		match, ok := nsec3Matching(ce, entries)
+		if !ok {
+			if CompareNames(ce, zone) == 0 {
+				break
+			}
+			continue
+		}
+		// An NSEC3 from the parent side of a delegation, or at a DNAME, does
+		// not prove anything about names below it
+		if (match.HasType(NS) && !match.HasType(SOA)) || match.HasType(DNAME) {
+			return "", nil, fmt.Errorf("%w: closest encloser %s is a delegation or DNAME",
+				ErrDenialNotProven, ce)
+		}
+		nextCloser := strings.Join(labels[i-1:], ".")
+		cover, ok := nsec3Covering(nextCloser, entries)
+		if !ok {
+			return "", nil, fmt.Errorf("%w: no NSEC3 covers next closer %s",
+				ErrDenialNotProven, nextCloser)
+		}
+		return ce, cover, nil
+	}
+	return "", nil, fmt.Errorf("%w: no closest encloser for %s", ErrDenialNotProven, qname)
+}
+
+// VerifyNSEC3NameError checks that the NSEC3 records in nsec3s prove that qname
+// does not exist, as described in RFC 5155 section 8.4. The signatures of the
+// NSEC3 records must be verified separately.
+func VerifyNSEC3NameError(qname string, nsec3s []Record) error {
+	entries, err := parseNsec3Set(nsec3s)
+	if err != nil {
+		return err
+	}
+	ce, _, err := nsec3ClosestEncloser(qname, entries)
+	if err != nil {
+		return err
+	}
+	if _, ok := nsec3Covering(wildcardName(ce), entries); !ok {
+		return fmt.Errorf("%w: no NSEC3 covers wildcard %s", ErrDenialNotProven, wildcardName(ce))
+	}
+	return nil
+}
+
+// VerifyNSEC3NoData checks that the NSEC3 records in nsec3s prove that qname
+// has no records of type qtype, as described in RFC 5155 sections 8.5 to 8.7.
+// Insecure delegations covered by an Opt-Out NSEC3 are accepted for DS
+// queries. The signatures of the NSEC3 records must be verified separately.
+func VerifyNSEC3NoData(qname string, qtype Type, nsec3s []Record) error {
+	entries, err := parseNsec3Set(nsec3s)
+	if err != nil {
+		return err
+	}
+	if n, ok := nsec3Matching(qname, entries); ok {
+		return checkNoDataTypes(qname, qtype, n.TypeBitMap)
+	}
+	ce, cover, err := nsec3ClosestEncloser(qname, entries)
+	if err != nil {
+		return err
+	}
+	if qtype == DS && cover.OptOut() {
+		return nil
+	}
+	if n, ok := nsec3Matching(wildcardName(ce), entries); ok {
+		return checkNoDataTypes(wildcardName(ce), qtype, n.TypeBitMap)
+	}
+	return fmt.Errorf("%w: no NSEC3 matches %s", ErrDenialNotProven, qname)
+}
@@ -0,0 +1,219 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func testNsecChain(names map[string][]Type) []Record {
+	var owners []string
+	for name := range names {
+		owners = append(owners, name)
+	}
+	sort.Slice(owners, func(i, j int) bool { return CompareNames(owners[i], owners[j]) < 0 })
+	var chain []Record
+	for i, owner := range owners {
+		chain = append(chain, Record{
+			Name: owner,
+			Type: NSEC,
+			Data: &Nsec{
+				NextDomain: owners[(i+1)%len(owners)],
+				TypeBitMap: names[owner],
+			},
+		})
+	}
+	return chain
+}
+
+func testNsec3Chain(zone string, names map[string][]Type, flags uint8) []Record {
+	salt := []byte("\xaa\xbb\xcc\xdd")
+	type entry struct {
+		hash  []byte
+		types []Type
+	}
+	var entries []entry
+	for name, types := range names {
+		hash, _ := HashName(name, Nsec3SHA1, 12, salt)
+		entries = append(entries, entry{hash: hash, types: types})
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].hash, entries[j].hash) < 0 })
+	var chain []Record
+	for i, e := range entries {
+		chain = append(chain, Record{
+			Name: strings.ToLower(nsec3Encoding.EncodeToString(e.hash)) + "." + zone,
+			Type: NSEC3,
+			Data: &Nsec3{
+				HashAlgorithm: Nsec3SHA1,
+				Flags:         flags,
+				Iterations:    12,
+				Salt:          salt,
+				NextHashed:    entries[(i+1)%len(entries)].hash,
+				TypeBitMap:    e.types,
+			},
+		})
+	}
+	return chain
+}
+
+var testZoneNames = map[string][]Type{
+	"example":     {NS, SOA, RRSIG, NSEC, DNSKEY},
+	"a.example":   {A, RRSIG, NSEC},
+	"c.example":   {MX, RRSIG, NSEC},
+	"sub.example": {NS},
+	"x.example":   {CNAME, RRSIG, NSEC},
+}
+
+func TestVerifyNSECNameError(t *testing.T) {
+	chain := testNsecChain(testZoneNames)
+	tests := []struct {
+		name    string
+		qname   string
+		nsecs   []Record
+		wantErr bool
+	}{
+		{name: "Name between records", qname: "b.example", nsecs: chain},
+		{name: "Name after last record", qname: "z.example", nsecs: chain},
+		{name: "Name below existing name", qname: "foo.a.example", nsecs: chain},
+		{name: "Existing name", qname: "a.example", nsecs: chain, wantErr: true},
+		{name: "Name below delegation", qname: "www.sub.example", nsecs: chain, wantErr: true},
+		{name: "Missing wildcard proof", qname: "b.example", nsecs: chain[1:2], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyNSECNameError(tt.qname, tt.nsecs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyNSECNameError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrDenialNotProven) {
+				t.Errorf("VerifyNSECNameError() error = %v, want %v", err, ErrDenialNotProven)
+			}
+		})
+	}
+}
+
+func TestVerifyNSECNoData(t *testing.T) {
+	chain := testNsecChain(testZoneNames)
+	wildcard := testNsecChain(map[string][]Type{
+		"example":   {NS, SOA},
+		"*.example": {TXT},
+		"a.example": {A},
+	})
+	emptyNonTerminal := testNsecChain(map[string][]Type{
+		"example":     {NS, SOA},
+		"a.example":   {A},
+		"x.b.example": {A},
+	})
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   Type
+		nsecs   []Record
+		wantErr bool
+	}{
+		{name: "Missing type", qname: "a.example", qtype: MX, nsecs: chain},
+		{name: "Existing type", qname: "a.example", qtype: A, nsecs: chain, wantErr: true},
+		{name: "CNAME", qname: "x.example", qtype: A, nsecs: chain, wantErr: true},
+		{name: "Missing DS at delegation", qname: "sub.example", qtype: DS, nsecs: chain},
+		{name: "Type at delegation", qname: "sub.example", qtype: A, nsecs: chain, wantErr: true},
+		{name: "DS at apex", qname: "example", qtype: DS, nsecs: chain, wantErr: true},
+		{name: "Wildcard without type", qname: "b.example", qtype: A, nsecs: wildcard},
+		{name: "Wildcard with type", qname: "b.example", qtype: TXT, nsecs: wildcard, wantErr: true},
+		{name: "Nonexistent name", qname: "b.example", qtype: A, nsecs: chain, wantErr: true},
+		{name: "Empty non-terminal", qname: "b.example", qtype: A, nsecs: emptyNonTerminal},
+		{name: "Name below empty non-terminal", qname: "y.b.example", qtype: A,
+			nsecs: emptyNonTerminal, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyNSECNoData(tt.qname, tt.qtype, tt.nsecs); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyNSECNoData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHashedOwner(t *testing.T) {
+	// Test vectors from RFC 5155 appendix A
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "example", want: "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom.example"},
+		{name: "a.example", want: "35mthgpgcu1qg68fab165klnsnk3dpvl.example"},
+		{name: "ns1.example", want: "2t7b4g4vsa5smi47k61mv5bv1a22bojr.example"},
+		{name: "*.w.example", want: "r53bq7cc2uvmubfu5ocmm6pers9tk9en.example"},
+		{name: "X.W.Example", want: "b4um86eghhds6nea196smvmlo4ors995.example"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HashedOwner(tt.name, "example", Nsec3SHA1, 12, []byte("\xaa\xbb\xcc\xdd"))
+			if err != nil || got != tt.want {
+				t.Errorf("HashedOwner() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+	if _, err := HashName("example", 2, 0, nil); err == nil {
+		t.Errorf("HashName() with unknown algorithm error = nil, want error")
+	}
+}
+
+func TestVerifyNSEC3NameError(t *testing.T) {
+	chain := testNsec3Chain("example", testZoneNames, 0)
+	dname := testNsec3Chain("example", map[string][]Type{
+		"example":   {NS, SOA},
+		"d.example": {DNAME},
+	}, 0)
+	tests := []struct {
+		name    string
+		qname   string
+		nsec3s  []Record
+		wantErr bool
+	}{
+		{name: "Nonexistent name", qname: "b.example", nsec3s: chain},
+		{name: "Nonexistent name below existing name", qname: "www.c.example", nsec3s: chain},
+		{name: "Existing name", qname: "a.example", nsec3s: chain, wantErr: true},
+		{name: "Name outside zone", qname: "b.other", nsec3s: chain, wantErr: true},
+		{name: "Name below delegation", qname: "www.sub.example", nsec3s: chain, wantErr: true},
+		{name: "Name below DNAME", qname: "www.d.example", nsec3s: dname, wantErr: true},
+		{name: "No NSEC3 records", qname: "b.example", nsec3s: nil, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyNSEC3NameError(tt.qname, tt.nsec3s); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyNSEC3NameError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyNSEC3NoData(t *testing.T) {
+	chain := testNsec3Chain("example", testZoneNames, 0)
+	optOut := testNsec3Chain("example", map[string][]Type{
+		"example":   {NS, SOA},
+		"a.example": {A},
+	}, Nsec3OptOut)
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   Type
+		nsec3s  []Record
+		wantErr bool
+	}{
+		{name: "Missing type", qname: "a.example", qtype: MX, nsec3s: chain},
+		{name: "Existing type", qname: "c.example", qtype: MX, nsec3s: chain, wantErr: true},
+		{name: "Missing DS at delegation", qname: "sub.example", qtype: DS, nsec3s: chain},
+		{name: "Opt-out insecure delegation", qname: "insecure.example", qtype: DS, nsec3s: optOut},
+		{name: "Opt-out without DS query", qname: "insecure.example", qtype: A, nsec3s: optOut, wantErr: true},
+		{name: "Nonexistent name without opt-out", qname: "b.example", qtype: DS, nsec3s: chain, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifyNSEC3NoData(tt.qname, tt.qtype, tt.nsec3s); (err != nil) != tt.wantErr {
+				t.Errorf("VerifyNSEC3NoData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+// DnskeyProtocol is the only valid protocol value of a DNSKEY record
+const DnskeyProtocol = 3
+
+// Dnskey implements interface RData for both DNSKEY and CDNSKEY records
+type Dnskey struct {
+	Flags     uint16
+	Protocol  uint8
+	Algorithm uint8
+	PublicKey []byte
+	length    uint16
+}
+
+// Parse implements DNSKEY parsing for interface RData
+func (k *Dnskey) Parse(buf *bytes.Buffer, _ int, _ *Domains) error {
+	if k.length < 4 || buf.Len() < int(k.length) {
+		return fmt.Errorf("unable to read DNSKEY: length %d, %d bytes left",
+			k.length, buf.Len())
+	}
+	binary.Read(buf, binary.BigEndian, &k.Flags)
+	k.Protocol, _ = buf.ReadByte()
+	k.Algorithm, _ = buf.ReadByte()
+	k.PublicKey = make([]byte, k.length-4)
+	copy(k.PublicKey, buf.Next(int(k.length-4)))
+	return nil
+}
+
+// Build implements DNSKEY building for interface RData
+func (k *Dnskey) Build(buf *bytes.Buffer, _ *Domains) error {
+	if err := binary.Write(buf, binary.BigEndian, k.Flags); err != nil {
+		return err
+	}
+	buf.WriteByte(k.Protocol)
+	buf.WriteByte(k.Algorithm)
+	buf.Write(k.PublicKey)
+	return nil
+}
+
+// PreBuild step, just returning record size
+func (k *Dnskey) PreBuild(_ *Record, _ *Domains) (int, error) {
+	return 4 + len(k.PublicKey), nil
+}
+
+// TransformName satisfies the interface
+func (*Dnskey) TransformName(name string) string { return name }
+
+// KeyTag calculates the key tag of the key as described in RFC 4034
+// appendix B
+func (k *Dnskey) KeyTag() uint16 {
+	buf := new(bytes.Buffer)
+	k.Build(buf, nil)
+	rdata := buf.Bytes()
+
+	if k.Algorithm == RSAMD5 {
+		// The key tag is the most significant 16 of the least significant 24
+		// bits of the modulus
+		if len(k.PublicKey) < 3 {
+			return 0
+		}
+		return binary.BigEndian.Uint16(k.PublicKey[len(k.PublicKey)-3:])
+	}
+
+	var ac uint32
+	for i, b := range rdata {
+		if i&1 == 1 {
+			ac += uint32(b)
+		} else {
+			ac += uint32(b) << 8
+		}
+	}
+	ac += ac >> 16 & 0xffff
+	return uint16(ac & 0xffff)
+}
+
+// IsZoneKey reports whether the Zone Key flag is set
+func (k *Dnskey) IsZoneKey() bool {
+	return k.Flags&DnskeyZoneKey == DnskeyZoneKey
+}
+
+// IsSEP reports whether the Secure Entry Point flag is set, which is commonly
+// used to mark a key signing key
+func (k *Dnskey) IsSEP() bool {
+	return k.Flags&DnskeySEP == DnskeySEP
+}
+
+// IsRevoked reports whether the key has been revoked as described in RFC 5011
+func (k *Dnskey) IsRevoked() bool {
+	return k.Flags&DnskeyRevoke == DnskeyRevoke
+}
+
+// parseText implements presentation format parsing of DNSKEY records
+func (k *Dnskey) parseText(t *rdataText) error {
+	var err error
+	if k.Flags, err = t.uint16("flags"); err != nil {
+		return err
+	}
+	if k.Protocol, err = t.uint8("protocol"); err != nil {
+		return err
+	}
+	if k.Algorithm, err = t.algorithm(); err != nil {
+		return err
+	}
+	k.PublicKey, err = t.base64("public key")
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (k *Dnskey) String() string {
+	return fmt.Sprintf("%d %d %d %s", k.Flags, k.Protocol, k.Algorithm,
+		base64.StdEncoding.EncodeToString(k.PublicKey))
+}
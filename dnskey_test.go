@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func mustDecodeBase64(s string) []byte {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Example keys from RFC 4034 section 5.4 and RFC 8080 section 6.1
+var (
+	rsaSHA1Key = &Dnskey{
+		Flags:     256,
+		Protocol:  3,
+		Algorithm: RSASHA1,
+		PublicKey: mustDecodeBase64("AQOeiiR0GOMYkDshWoSKz9XzfwJr1AYtsmx3TGkJaNXVbfi/" +
+			"2pHm822aJ5iI9BMzNXxeYCmZDRD99WYwYqUSdjMmmAphXdvx" +
+			"egXd/M5+X7OrzKBaMbCVdFLUUh6DhweJBjEVv5f2wwjM9Xzc" +
+			"nOf+EPbtG9DMBmADjFDc2w/rljwvFw=="),
+	}
+	ed25519Key = &Dnskey{
+		Flags:     257,
+		Protocol:  3,
+		Algorithm: ED25519,
+		PublicKey: mustDecodeBase64("l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4="),
+	}
+)
+
+func TestDnskey_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    *Dnskey
+		wantErr bool
+	}{
+		{
+			name: "Ed25519 key",
+			buf:  append([]byte("\x01\x01\x03\x0f"), ed25519Key.PublicKey...),
+			want: ed25519Key,
+		},
+		{
+			name:    "Missing algorithm",
+			buf:     []byte("\x01\x01\x03"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Dnskey{length: uint16(len(tt.buf))}
+			if err := k.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Dnskey.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			k.length = 0
+			if !reflect.DeepEqual(k, tt.want) {
+				t.Errorf("Dnskey.Parse() = %+v, want %+v", k, tt.want)
+			}
+		})
+	}
+}
+
+func TestDnskey_Build(t *testing.T) {
+	want := append([]byte("\x01\x01\x03\x0f"), ed25519Key.PublicKey...)
+	buf := new(bytes.Buffer)
+	if length, err := ed25519Key.PreBuild(&Record{}, NewDomains()); err != nil || length != len(want) {
+		t.Errorf("Dnskey.PreBuild() = %v, %v, want %v", length, err, len(want))
+		return
+	}
+	if err := ed25519Key.Build(buf, NewDomains()); err != nil {
+		t.Errorf("Dnskey.Build() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(buf.Bytes(), want) {
+		t.Errorf("Dnskey.Build() = %v, want %v", buf.Bytes(), want)
+	}
+}
+
+func TestDnskey_KeyTag(t *testing.T) {
+	tests := []struct {
+		name string
+		key  *Dnskey
+		want uint16
+	}{
+		{name: "RSASHA1 zone key", key: rsaSHA1Key, want: 60485},
+		{name: "Ed25519 key signing key", key: ed25519Key, want: 3613},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key.KeyTag(); got != tt.want {
+				t.Errorf("Dnskey.KeyTag() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDnskey_Flags(t *testing.T) {
+	if !ed25519Key.IsZoneKey() || !ed25519Key.IsSEP() || ed25519Key.IsRevoked() {
+		t.Errorf("Dnskey flags of KSK wrong: %d", ed25519Key.Flags)
+	}
+	if !rsaSHA1Key.IsZoneKey() || rsaSHA1Key.IsSEP() {
+		t.Errorf("Dnskey flags of ZSK wrong: %d", rsaSHA1Key.Flags)
+	}
+}
@@ -0,0 +1,150 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// DNSSEC algorithm numbers as defined in the IANA registry
+const (
+	RSAMD5           uint8 = 1
+	DSA              uint8 = 3
+	RSASHA1          uint8 = 5
+	DSANSEC3SHA1     uint8 = 6
+	RSASHA1NSEC3SHA1 uint8 = 7
+	RSASHA256        uint8 = 8
+	RSASHA512        uint8 = 10
+	ECCGOST          uint8 = 12
+	ECDSAP256SHA256  uint8 = 13
+	ECDSAP384SHA384  uint8 = 14
+	ED25519          uint8 = 15
+	ED448            uint8 = 16
+)
+
+// AlgorithmStrings holds name mapping for DNSSEC algorithm constants
+var AlgorithmStrings = map[uint8]string{
+	RSAMD5:           "RSAMD5",
+	DSA:              "DSA",
+	RSASHA1:          "RSASHA1",
+	DSANSEC3SHA1:     "DSA-NSEC3-SHA1",
+	RSASHA1NSEC3SHA1: "RSASHA1-NSEC3-SHA1",
+	RSASHA256:        "RSASHA256",
+	RSASHA512:        "RSASHA512",
+	ECCGOST:          "ECC-GOST",
+	ECDSAP256SHA256:  "ECDSAP256SHA256",
+	ECDSAP384SHA384:  "ECDSAP384SHA384",
+	ED25519:          "ED25519",
+	ED448:            "ED448",
+}
+
+// DS digest types as defined in the IANA registry
+const (
+	DigestSHA1   uint8 = 1
+	DigestSHA256 uint8 = 2
+	DigestGOST94 uint8 = 3
+	DigestSHA384 uint8 = 4
+)
+
+// DNSKEY flags as defined in RFC 4034 and RFC 5011
+const (
+	DnskeyZoneKey uint16 = 0x0100
+	DnskeyRevoke  uint16 = 0x0080
+	DnskeySEP     uint16 = 0x0001
+)
+
+// NSEC3 hash algorithm and flags as defined in RFC 5155
+const (
+	Nsec3SHA1   uint8 = 1
+	Nsec3OptOut uint8 = 0x01
+)
+
+// rrsigLabels returns the label count of name as used in the RRSIG Labels
+// field, not counting the root or a leading wildcard label
+func rrsigLabels(name string) uint8 {
+	labels := nameLabels(name)
+	if len(labels) > 0 && labels[0] == "*" {
+		return uint8(len(labels) - 1)
+	}
+	return uint8(len(labels))
+}
+
+// canonicalRData builds the RDATA of r in canonical form: uncompressed and
+// with the embedded names of the types listed in RFC 4034 section 6.2 (as
+// updated by RFC 6840) in lower case
+func canonicalRData(r *Record) ([]byte, error) {
+	var data RData
+	switch d := r.Data.(type) {
+	case *Ns:
+		data = &Ns{Name: CanonicalName(d.Name)}
+	case *CName:
+		data = &CName{Name: CanonicalName(d.Name)}
+	case *Ptr:
+		data = &Ptr{Name: CanonicalName(d.Name)}
+	case *Mx:
+		data = &Mx{Preference: d.Preference, Exchange: CanonicalName(d.Exchange)}
+	case *Soa:
+		s := *d
+		s.MName, s.RName = CanonicalName(d.MName), CanonicalName(d.RName)
+		data = &s
+	case *Srv:
+		s := *d
+		s.Target = CanonicalName(d.Target)
+		data = &s
+	case *Rrsig:
+		s := *d
+		s.SignerName = CanonicalName(d.SignerName)
+		data = &s
+	default:
+		data = r.Data
+	}
+	rr := *r
+	if _, err := data.PreBuild(&rr, nil); err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := data.Build(buf, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// signedData returns the data covered by the signature sig over rrset as
+// described in RFC 4034 section 3.1.8.1, with the RRset in canonical order
+func signedData(sig *Rrsig, rrset []Record) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	sig.buildFixed(buf)
+	buf.WriteString(BuildName(CanonicalName(sig.SignerName), nil))
+
+	rdatas := make([][]byte, 0, len(rrset))
+	for i := range rrset {
+		rdata, err := canonicalRData(&rrset[i])
+		if err != nil {
+			return nil, err
+		}
+		rdatas = append(rdatas, rdata)
+	}
+	sort.Slice(rdatas, func(i, j int) bool {
+		return bytes.Compare(rdatas[i], rdatas[j]) < 0
+	})
+
+	owner := CanonicalName(rrset[0].Name)
+	if labels := nameLabels(owner); int(sig.Labels) < len(labels) {
+		owner = strings.Join(append([]string{"*"}, labels[len(labels)-int(sig.Labels):]...), ".")
+	}
+	ownerBytes := BuildName(owner, nil)
+	for i, rdata := range rdatas {
+		// Duplicate records are only included once
+		if i > 0 && bytes.Equal(rdata, rdatas[i-1]) {
+			continue
+		}
+		buf.WriteString(ownerBytes)
+		binary.Write(buf, binary.BigEndian, rrset[0].Type)
+		binary.Write(buf, binary.BigEndian, rrset[0].Class)
+		binary.Write(buf, binary.BigEndian, sig.OriginalTTL)
+		binary.Write(buf, binary.BigEndian, uint16(len(rdata)))
+		buf.Write(rdata)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,51 @@
+package dns
+
+import (
+	"testing"
+)
+
+func TestRrsigLabels(t *testing.T) {
+	tests := []struct {
+		name string
+		want uint8
+	}{
+		{name: "", want: 0},
+		{name: "example.com", want: 2},
+		{name: "*.example.com", want: 2},
+		{name: "www.example.com", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rrsigLabels(tt.name); got != tt.want {
+				t.Errorf("rrsigLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalRData(t *testing.T) {
+	tests := []struct {
+		name   string
+		record Record
+		want   string
+	}{
+		{
+			name:   "MX is lower cased and uncompressed",
+			record: Record{Type: MX, Data: &Mx{Preference: 10, Exchange: "Mail.Example.COM"}},
+			want:   "\x00\x0a\x04mail\x07example\x03com\x00",
+		},
+		{
+			name:   "TXT keeps case",
+			record: Record{Type: TXT, Data: &Txt{Data: []string{"Hello"}}},
+			want:   "\x05Hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalRData(&tt.record)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("canonicalRData() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -1,48 +1,83 @@
 package dns
 
-// Domains holds maps for parsing and building CNAME pointers
+import "sync"
+
+// Domains holds maps for parsing and building CNAME pointers. A nil *Domains
+// is valid and disables name compression, while zero value domains create
+// their build map when first used.
 type Domains struct {
 	parsePtr map[int]string
 	buildPtr map[string]int
+
+	// msg is the message being unpacked, pointers are then resolved by
+	// reading the name they point to instead of through parsePtr
+	msg []byte
 }
 
 // GetParse returns a domain for a given pointer
 func (p *Domains) GetParse(ptr int) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	if p.msg != nil {
+		name, _, err := unpackName(p.msg, ptr)
+		return name, err == nil
+	}
 	name, ok := p.parsePtr[ptr]
 	return name, ok
 }
 
-// GetBuild returns af pointer for a given name
+// GetBuild returns af pointer for a given name. Names are matched ignoring
+// case, so a pointer may refer to the name written in another case.
 func (p *Domains) GetBuild(name string) (int, bool) {
-	ptr, ok := p.buildPtr[name]
+	if p == nil {
+		return 0, false
+	}
+	ptr, ok := p.buildPtr[CanonicalName(name)]
 	return ptr, ok
 }
 
 // SetParse adds parse pointers to the domain map
 func (p *Domains) SetParse(ptr int, name string) {
-	ok := true
-	for i, c := range name {
-		if ok {
-			p.parsePtr[ptr+i] = name[i:]
-			ok = false
-		} else if c == '.' {
-			ok = true
-		}
+	if p == nil || p.parsePtr == nil {
+		return
 	}
+	eachSuffix(name, func(suffix string, offset int) bool {
+		p.parsePtr[ptr+offset] = suffix
+		return true
+	})
 }
 
-// SetBuild adds build pointers to the domain map
+// SetBuild adds build pointers to the domain map, creating it if needed.
+// Offsets beyond the reach of a 14 bit pointer are not added.
 func (p *Domains) SetBuild(ptr int, name string) {
-	ok := true
-	for i, c := range name {
-		if ok {
-			if _, found := p.buildPtr[name[i:]]; !found {
-				p.buildPtr[name[i:]] = ptr + i
-			}
-			ok = false
-		} else if c == '.' {
-			ok = true
+	if p == nil {
+		return
+	}
+	if p.buildPtr == nil {
+		p.buildPtr = map[string]int{}
+	}
+	eachSuffix(CanonicalName(name), func(suffix string, offset int) bool {
+		if ptr+offset > PointerMask {
+			return false
+		}
+		if _, found := p.buildPtr[suffix]; !found {
+			p.buildPtr[suffix] = ptr + offset
 		}
+		return true
+	})
+}
+
+// eachSuffix calls f with every suffix of name starting at a label, and the
+// offset of that label on the wire, until f returns false
+func eachSuffix(name string, f func(suffix string, offset int) bool) {
+	index, offset := 0, 0
+	for _, label := range nameLabels(name) {
+		if !f(name[index:], offset) {
+			return
+		}
+		index += len(label) + 1
+		offset += len(rawLabel(label)) + 1
 	}
 }
 
@@ -50,3 +85,33 @@ func (p *Domains) SetBuild(ptr int, name string) {
 func NewDomains() *Domains {
 	return &Domains{parsePtr: map[int]string{}, buildPtr: map[string]int{}}
 }
+
+// Reset clears all names, so the domains can be used for another message
+// without allocating new maps. Like the other methods it does nothing on nil
+// domains.
+func (p *Domains) Reset() {
+	if p == nil {
+		return
+	}
+	clear(p.parsePtr)
+	clear(p.buildPtr)
+	p.msg = nil
+}
+
+var domainsPool = sync.Pool{New: func() any { return NewDomains() }}
+
+// AcquireDomains returns empty domains from a pool shared by all goroutines.
+// Call Release when the message is built or parsed.
+func AcquireDomains() *Domains {
+	return domainsPool.Get().(*Domains)
+}
+
+// Release resets the domains and returns them to the pool of AcquireDomains.
+// They must not be used afterwards.
+func (p *Domains) Release() {
+	if p == nil || p.parsePtr == nil || p.buildPtr == nil {
+		return
+	}
+	p.Reset()
+	domainsPool.Put(p)
+}
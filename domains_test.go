@@ -1,6 +1,7 @@
 package dns
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -66,7 +67,42 @@ func TestDomains_SetBuild(t *testing.T) {
 			},
 			want: map[string]int{"domain.test": 0, "test": 7},
 		},
-		// TODO: Add test cases.
+		{
+			name:   "Mixed case domain",
+			fields: fields{buildPtr: map[string]int{}},
+			args: args{
+				ptr:  12,
+				name: "WWW.Domain.test",
+			},
+			want: map[string]int{"www.domain.test": 12, "domain.test": 16, "test": 23},
+		},
+		{
+			name:   "Escaped labels",
+			fields: fields{buildPtr: map[string]int{}},
+			args: args{
+				ptr:  0,
+				name: `My\.Printer.\000.local`,
+			},
+			want: map[string]int{`my\.printer.\000.local`: 0, `\000.local`: 11, "local": 13},
+		},
+		{
+			name:   "Beyond pointer range",
+			fields: fields{buildPtr: map[string]int{}},
+			args: args{
+				ptr:  PointerMask - 3,
+				name: "domain.test",
+			},
+			want: map[string]int{"domain.test": PointerMask - 3},
+		},
+		{
+			name:   "Zero value domains",
+			fields: fields{},
+			args: args{
+				ptr:  0,
+				name: "domain.test",
+			},
+			want: map[string]int{"domain.test": 0, "test": 7},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -74,6 +110,9 @@ func TestDomains_SetBuild(t *testing.T) {
 				buildPtr: tt.fields.buildPtr,
 			}
 			d.SetBuild(tt.args.ptr, tt.args.name)
+			if len(d.buildPtr) != len(tt.want) {
+				t.Errorf("Domains.SetBuild() domains = %v, want %v", d.buildPtr, tt.want)
+			}
 			for k, v := range tt.want {
 				if vv, ok := d.GetBuild(k); !ok || vv != v {
 					t.Errorf("Domains.SetBuild() domains[%v] = %v, want %v", k, v, vv)
@@ -82,3 +121,98 @@ func TestDomains_SetBuild(t *testing.T) {
 		})
 	}
 }
+
+func TestDomains_Reset(t *testing.T) {
+	d := NewDomains()
+	d.SetBuild(12, "www.example.com")
+	d.SetParse(12, "www.example.com")
+	d.Reset()
+	if _, ok := d.GetBuild("example.com"); ok {
+		t.Error("Domains.GetBuild() after Reset found a name")
+	}
+	if _, ok := d.GetParse(16); ok {
+		t.Error("Domains.GetParse() after Reset found a name")
+	}
+	d.SetBuild(0, "example.com")
+	if ptr, ok := d.GetBuild("example.com"); !ok || ptr != 0 {
+		t.Errorf("Domains.GetBuild() after Reset = %d, %v, want 0, true", ptr, ok)
+	}
+	var nilDomains *Domains
+	nilDomains.Reset()
+}
+
+func TestAcquireDomains(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		d := AcquireDomains()
+		if len(d.buildPtr) != 0 || len(d.parsePtr) != 0 {
+			t.Fatalf("AcquireDomains() = %v, want empty domains", d)
+		}
+		d.SetBuild(12, "www.example.com")
+		d.Release()
+	}
+	// Domains not made for building, like those of Unpack, are not pooled
+	(&Domains{msg: []byte{0}}).Release()
+	var nilDomains *Domains
+	nilDomains.Release()
+}
+
+func BenchmarkMessage_Build(b *testing.B) {
+	m := testMessage()
+	buf := new(bytes.Buffer)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		m.Build(buf, NewDomains())
+	}
+}
+
+func BenchmarkMessage_BuildPooled(b *testing.B) {
+	m := testMessage()
+	buf := new(bytes.Buffer)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		d := AcquireDomains()
+		m.Build(buf, d)
+		d.Release()
+	}
+}
+
+func BenchmarkMessage_BuildReset(b *testing.B) {
+	m := testMessage()
+	buf := new(bytes.Buffer)
+	d := NewDomains()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		d.Reset()
+		m.Build(buf, d)
+	}
+}
+
+func BenchmarkMessage_PackTo(b *testing.B) {
+	m := testMessage()
+	out := make([]byte, 0, 512)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out, _ = m.PackTo(out)
+	}
+}
+
+func TestDomains_BuildWithoutMaps(t *testing.T) {
+	m := testAnswer(testQuery(), "192.0.2.1")
+	want := new(bytes.Buffer)
+	if err := m.Build(want, NewDomains()); err != nil {
+		t.Fatal(err)
+	}
+	// Domains made for parsing a message have no maps
+	for _, d := range []*Domains{{}, {msg: want.Bytes()}} {
+		got := new(bytes.Buffer)
+		if err := m.Build(got, d); err != nil {
+			t.Fatalf("Message.Build() error = %v", err)
+		}
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Errorf("Message.Build() = %x, want %x", got.Bytes(), want.Bytes())
+		}
+	}
+}
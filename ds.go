@@ -0,0 +1,115 @@
+package dns
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Ds implements interface RData for both DS and CDS records
+type Ds struct {
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     []byte
+	length     uint16
+}
+
+// Parse implements DS parsing for interface RData
+func (d *Ds) Parse(buf *bytes.Buffer, _ int, _ *Domains) error {
+	if d.length < 4 || buf.Len() < int(d.length) {
+		return fmt.Errorf("unable to read DS: length %d, %d bytes left",
+			d.length, buf.Len())
+	}
+	binary.Read(buf, binary.BigEndian, &d.KeyTag)
+	d.Algorithm, _ = buf.ReadByte()
+	d.DigestType, _ = buf.ReadByte()
+	d.Digest = make([]byte, d.length-4)
+	copy(d.Digest, buf.Next(int(d.length-4)))
+	return nil
+}
+
+// Build implements DS building for interface RData
+func (d *Ds) Build(buf *bytes.Buffer, _ *Domains) error {
+	if err := binary.Write(buf, binary.BigEndian, d.KeyTag); err != nil {
+		return err
+	}
+	buf.WriteByte(d.Algorithm)
+	buf.WriteByte(d.DigestType)
+	buf.Write(d.Digest)
+	return nil
+}
+
+// PreBuild step, just returning record size
+func (d *Ds) PreBuild(_ *Record, _ *Domains) (int, error) {
+	return 4 + len(d.Digest), nil
+}
+
+// TransformName satisfies the interface
+func (*Ds) TransformName(name string) string { return name }
+
+// NewDs creates the DS for key, owned by owner, using the digest type given
+// as described in RFC 4034 section 5.1.4
+func NewDs(owner string, key *Dnskey, digestType uint8) (*Ds, error) {
+	var h hash.Hash
+	switch digestType {
+	case DigestSHA1:
+		h = sha1.New()
+	case DigestSHA256:
+		h = sha256.New()
+	case DigestSHA384:
+		h = sha512.New384()
+	default:
+		return nil, fmt.Errorf("unsupported DS digest type: %d", digestType)
+	}
+	h.Write([]byte(BuildName(CanonicalName(owner), nil)))
+	rdata := new(bytes.Buffer)
+	key.Build(rdata, nil)
+	h.Write(rdata.Bytes())
+	return &Ds{
+		KeyTag:     key.KeyTag(),
+		Algorithm:  key.Algorithm,
+		DigestType: digestType,
+		Digest:     h.Sum(nil),
+	}, nil
+}
+
+// Matches reports whether the DS refers to key owned by owner
+func (d *Ds) Matches(owner string, key *Dnskey) bool {
+	if d.KeyTag != key.KeyTag() || d.Algorithm != key.Algorithm {
+		return false
+	}
+	other, err := NewDs(owner, key, d.DigestType)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(d.Digest, other.Digest)
+}
+
+// parseText implements presentation format parsing of DS records
+func (d *Ds) parseText(t *rdataText) error {
+	var err error
+	if d.KeyTag, err = t.uint16("key tag"); err != nil {
+		return err
+	}
+	if d.Algorithm, err = t.algorithm(); err != nil {
+		return err
+	}
+	if d.DigestType, err = t.uint8("digest type"); err != nil {
+		return err
+	}
+	d.Digest, err = t.hex("digest")
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (d *Ds) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType,
+		strings.ToUpper(hex.EncodeToString(d.Digest)))
+}
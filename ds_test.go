@@ -0,0 +1,130 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Example DS from RFC 4034 section 5.4
+var rsaSHA1Ds = &Ds{
+	KeyTag:     60485,
+	Algorithm:  RSASHA1,
+	DigestType: DigestSHA1,
+	Digest:     mustDecodeHex("2bb183af5f22588179a53b0a98631fad1a292118"),
+}
+
+func TestDs_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    *Ds
+		wantErr bool
+	}{
+		{
+			name: "SHA1 digest",
+			buf:  append([]byte("\xec\x45\x05\x01"), rsaSHA1Ds.Digest...),
+			want: rsaSHA1Ds,
+		},
+		{
+			name:    "Missing digest type",
+			buf:     []byte("\xec\x45\x05"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Ds{length: uint16(len(tt.buf))}
+			if err := d.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Ds.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			d.length = 0
+			if !reflect.DeepEqual(d, tt.want) {
+				t.Errorf("Ds.Parse() = %+v, want %+v", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestDs_Build(t *testing.T) {
+	want := append([]byte("\xec\x45\x05\x01"), rsaSHA1Ds.Digest...)
+	buf := new(bytes.Buffer)
+	if length, err := rsaSHA1Ds.PreBuild(&Record{}, NewDomains()); err != nil || length != len(want) {
+		t.Errorf("Ds.PreBuild() = %v, %v, want %v", length, err, len(want))
+		return
+	}
+	if err := rsaSHA1Ds.Build(buf, NewDomains()); err != nil {
+		t.Errorf("Ds.Build() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(buf.Bytes(), want) {
+		t.Errorf("Ds.Build() = %v, want %v", buf.Bytes(), want)
+	}
+}
+
+func TestNewDs(t *testing.T) {
+	tests := []struct {
+		name       string
+		owner      string
+		key        *Dnskey
+		digestType uint8
+		want       []byte
+		wantErr    bool
+	}{
+		{
+			name:       "RFC 4034 SHA1 digest",
+			owner:      "dskey.example.com",
+			key:        rsaSHA1Key,
+			digestType: DigestSHA1,
+			want:       rsaSHA1Ds.Digest,
+		},
+		{
+			name:       "RFC 8080 SHA256 digest with mixed case owner",
+			owner:      "Example.COM",
+			key:        ed25519Key,
+			digestType: DigestSHA256,
+			want:       mustDecodeHex("3aa5ab37efce57f737fc1627013fee07bdf241bd10f3b1964ab55c78e79a304b"),
+		},
+		{
+			name:       "Unsupported digest",
+			owner:      "example.com",
+			key:        ed25519Key,
+			digestType: DigestGOST94,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDs(tt.owner, tt.key, tt.digestType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewDs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.Digest, tt.want) || got.KeyTag != tt.key.KeyTag() {
+				t.Errorf("NewDs() = %x, want %x", got.Digest, tt.want)
+			}
+			if !got.Matches(tt.owner, tt.key) {
+				t.Errorf("Ds.Matches() = false, want true")
+			}
+			if got.Matches("other.example", tt.key) {
+				t.Errorf("Ds.Matches() = true for other owner, want false")
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+)
+
+// Address families of EDNSClientSubnet from the IANA registry
+const (
+	FamilyIPv4 = 1
+	FamilyIPv6 = 2
+)
+
+// EDNSClientSubnet holds the client subnet a query is sent on behalf of (RFC
+// 7871). SourcePrefix is the number of significant bits of Address, and
+// ScopePrefix the number of bits the answer of a server covers.
+type EDNSClientSubnet struct {
+	Family       uint16
+	SourcePrefix uint8
+	ScopePrefix  uint8
+	Address      netip.Addr
+}
+
+// NewClientSubnet returns the option for a query sent on behalf of the clients
+// in prefix. The address is masked to the prefix, so only the network is sent.
+func NewClientSubnet(prefix netip.Prefix) *EDNSClientSubnet {
+	prefix = prefix.Masked()
+	e := &EDNSClientSubnet{
+		Family:       FamilyIPv6,
+		SourcePrefix: uint8(prefix.Bits()),
+		Address:      prefix.Addr(),
+	}
+	if e.Address.Is4() {
+		e.Family = FamilyIPv4
+	}
+	return e
+}
+
+// Code returns the option code of the option
+func (*EDNSClientSubnet) Code() uint16 { return OptionCodeClientSubnet }
+
+// Parse implements parsing of the option data. The address is sent truncated
+// to the bytes covering SourcePrefix, and bits beyond it must be zero.
+func (e *EDNSClientSubnet) Parse(b []byte) error {
+	if len(b) < 4 {
+		return fmt.Errorf("invalid client subnet length: %d", len(b))
+	}
+	e.Family = binary.BigEndian.Uint16(b)
+	e.SourcePrefix, e.ScopePrefix = b[2], b[3]
+	addr := b[4:]
+	switch e.Family {
+	case FamilyIPv4:
+		var a [4]byte
+		if len(addr) > len(a) {
+			return fmt.Errorf("client subnet address too long: %d", len(addr))
+		}
+		copy(a[:], addr)
+		e.Address = netip.AddrFrom4(a)
+	case FamilyIPv6:
+		var a [16]byte
+		if len(addr) > len(a) {
+			return fmt.Errorf("client subnet address too long: %d", len(addr))
+		}
+		copy(a[:], addr)
+		e.Address = netip.AddrFrom16(a)
+	default:
+		return fmt.Errorf("unknown client subnet family: %d", e.Family)
+	}
+	if int(e.SourcePrefix) > e.Address.BitLen() || int(e.ScopePrefix) > e.Address.BitLen() {
+		return fmt.Errorf("client subnet prefix out of range: %d/%d", e.SourcePrefix,
+			e.ScopePrefix)
+	}
+	if len(addr) != (int(e.SourcePrefix)+7)/8 {
+		return fmt.Errorf("client subnet address length %d does not match prefix %d",
+			len(addr), e.SourcePrefix)
+	}
+	if e.Prefix().Addr() != e.Address {
+		return fmt.Errorf("client subnet address %s has bits set beyond prefix %d",
+			e.Address, e.SourcePrefix)
+	}
+	return nil
+}
+
+// Build implements building of the option data. Bits of Address beyond
+// SourcePrefix are not sent.
+func (e *EDNSClientSubnet) Build(buf *bytes.Buffer) error {
+	if (e.Family != FamilyIPv4 || !e.Address.Is4()) &&
+		(e.Family != FamilyIPv6 || !e.Address.Is6()) {
+		return fmt.Errorf("address %s does not match client subnet family %d", e.Address,
+			e.Family)
+	}
+	if int(e.SourcePrefix) > e.Address.BitLen() || int(e.ScopePrefix) > e.Address.BitLen() {
+		return fmt.Errorf("client subnet prefix out of range: %d/%d", e.SourcePrefix,
+			e.ScopePrefix)
+	}
+	addr := e.Prefix().Addr().AsSlice()
+	binary.Write(buf, binary.BigEndian, e.Family)
+	buf.WriteByte(e.SourcePrefix)
+	buf.WriteByte(e.ScopePrefix)
+	buf.Write(addr[:(int(e.SourcePrefix)+7)/8])
+	return nil
+}
+
+// Prefix returns the client subnet the query was sent for
+func (e *EDNSClientSubnet) Prefix() netip.Prefix {
+	p, _ := e.Address.Prefix(int(e.SourcePrefix))
+	return p
+}
+
+// Scope returns the subnet an answer is valid for, which may be shorter or
+// longer than the prefix of the query. A scope of /0 means the answer is valid
+// for all clients.
+func (e *EDNSClientSubnet) Scope() netip.Prefix {
+	p, _ := e.Address.Prefix(int(e.ScopePrefix))
+	return p
+}
+
+// Reply returns the option for a response to a query carrying e, with the
+// answer covering the first scope bits of the client address
+func (e *EDNSClientSubnet) Reply(scope uint8) *EDNSClientSubnet {
+	return &EDNSClientSubnet{
+		Family:       e.Family,
+		SourcePrefix: e.SourcePrefix,
+		ScopePrefix:  scope,
+		Address:      e.Prefix().Addr(),
+	}
+}
+
+// ClientSubnet returns the client subnet option of m, or nil if it has none
+func (m *Message) ClientSubnet() *EDNSClientSubnet {
+	opt := m.Opt()
+	if opt == nil {
+		return nil
+	}
+	if o, ok := opt.Option(OptionCodeClientSubnet); ok {
+		if e, ok := o.(*EDNSClientSubnet); ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// SetClientSubnet sets the client subnet option of m, adding an OPT record if
+// needed. Use NewClientSubnet for queries and Reply of the query option for
+// responses.
+func (m *Message) SetClientSubnet(e *EDNSClientSubnet) {
+	m.SetOption(e)
+}
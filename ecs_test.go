@@ -0,0 +1,162 @@
+package dns
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestNewClientSubnet(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix netip.Prefix
+		want   *EDNSClientSubnet
+	}{
+		{
+			name:   "IPv4 host bits are masked",
+			prefix: netip.MustParsePrefix("192.0.2.77/24"),
+			want: &EDNSClientSubnet{Family: FamilyIPv4, SourcePrefix: 24,
+				Address: netip.MustParseAddr("192.0.2.0")},
+		},
+		{
+			name:   "IPv6",
+			prefix: netip.MustParsePrefix("2001:db8:1:2::/56"),
+			want: &EDNSClientSubnet{Family: FamilyIPv6, SourcePrefix: 56,
+				Address: netip.MustParseAddr("2001:db8:1::")},
+		},
+		{
+			name:   "No client information",
+			prefix: netip.MustParsePrefix("0.0.0.0/0"),
+			want: &EDNSClientSubnet{Family: FamilyIPv4,
+				Address: netip.MustParseAddr("0.0.0.0")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewClientSubnet(tt.prefix)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewClientSubnet() = %+v, want %+v", got, tt.want)
+			}
+			if got.Prefix() != tt.prefix.Masked() {
+				t.Errorf("EDNSClientSubnet.Prefix() = %v, want %v", got.Prefix(), tt.prefix.Masked())
+			}
+		})
+	}
+}
+
+func TestEDNSClientSubnet_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    *EDNSClientSubnet
+		wantErr bool
+	}{
+		{
+			name: "IPv4 with scope",
+			data: []byte("\x00\x01\x16\x18\xc6\x33\x64"),
+			want: &EDNSClientSubnet{Family: FamilyIPv4, SourcePrefix: 22, ScopePrefix: 24,
+				Address: netip.MustParseAddr("198.51.100.0")},
+		},
+		{
+			name: "Zero prefix",
+			data: []byte("\x00\x02\x00\x00"),
+			want: &EDNSClientSubnet{Family: FamilyIPv6, Address: netip.IPv6Unspecified()},
+		},
+		{
+			name:    "Bits set beyond prefix",
+			data:    []byte("\x00\x01\x16\x00\xc6\x33\x67"),
+			wantErr: true,
+		},
+		{
+			name:    "Address shorter than prefix",
+			data:    []byte("\x00\x01\x18\x00\xc6\x33"),
+			wantErr: true,
+		},
+		{
+			name:    "Prefix longer than address",
+			data:    []byte("\x00\x01\x21\x00\xc6\x33\x64\x00\x00"),
+			wantErr: true,
+		},
+		{
+			name:    "Unknown family",
+			data:    []byte("\x00\x03\x00\x00"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &EDNSClientSubnet{}
+			if err := e.Parse(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("EDNSClientSubnet.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(e, tt.want) {
+				t.Errorf("EDNSClientSubnet.Parse() = %+v, want %+v", e, tt.want)
+			}
+		})
+	}
+}
+
+func TestEDNSClientSubnet_Reply(t *testing.T) {
+	query := NewClientSubnet(netip.MustParsePrefix("198.51.100.0/24"))
+	tests := []struct {
+		name  string
+		scope uint8
+		want  netip.Prefix
+	}{
+		{name: "Same as source", scope: 24, want: netip.MustParsePrefix("198.51.100.0/24")},
+		{name: "Shorter than source", scope: 16, want: netip.MustParsePrefix("198.51.0.0/16")},
+		{name: "Valid for all clients", scope: 0, want: netip.MustParsePrefix("0.0.0.0/0")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := query.Reply(tt.scope)
+			if got.Prefix() != query.Prefix() {
+				t.Errorf("EDNSClientSubnet.Reply() prefix = %v, want %v", got.Prefix(), query.Prefix())
+			}
+			if got.Scope() != tt.want {
+				t.Errorf("EDNSClientSubnet.Reply() scope = %v, want %v", got.Scope(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage_ClientSubnet(t *testing.T) {
+	query := &Message{ID: 1, Questions: []Question{{Domain: "example.com", Type: A, Class: IN}}}
+	if query.ClientSubnet() != nil {
+		t.Fatalf("Message.ClientSubnet() = %v, want nil", query.ClientSubnet())
+	}
+	query.SetClientSubnet(NewClientSubnet(netip.MustParsePrefix("2001:db8::/48")))
+	b, err := query.Pack()
+	if err != nil {
+		t.Fatalf("Message.Pack() error = %v", err)
+	}
+	received, err := Unpack(b)
+	if err != nil {
+		t.Fatalf("Unpack() error = %v", err)
+	}
+	if received.Opt() == nil || received.Opt().UDPSize != DefaultEDNSSize {
+		t.Errorf("Message.SetClientSubnet() OPT = %+v, want UDP size %d", received.Opt(),
+			DefaultEDNSSize)
+	}
+
+	reply := ReplyTo(received)
+	reply.SetClientSubnet(received.ClientSubnet().Reply(32))
+	if b, err = reply.Pack(); err != nil {
+		t.Fatalf("Message.Pack() error = %v", err)
+	}
+	resp, err := Unpack(b)
+	if err != nil {
+		t.Fatalf("Unpack() error = %v", err)
+	}
+	ecs := resp.ClientSubnet()
+	if ecs == nil {
+		t.Fatal("Message.ClientSubnet() = nil")
+	}
+	if want := netip.MustParsePrefix("2001:db8::/48"); ecs.Prefix() != want {
+		t.Errorf("Message.ClientSubnet() prefix = %v, want %v", ecs.Prefix(), want)
+	}
+	if want := netip.MustParsePrefix("2001:db8::/32"); ecs.Scope() != want {
+		t.Errorf("Message.ClientSubnet() scope = %v, want %v", ecs.Scope(), want)
+	}
+}
@@ -0,0 +1,135 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// ExtendedErrorCode is the INFO-CODE of an extended DNS error (RFC 8914)
+type ExtendedErrorCode uint16
+
+// List of registered ExtendedErrorCode constants
+const (
+	ExtendedErrorOther                       ExtendedErrorCode = 0
+	ExtendedErrorUnsupportedDNSKEYAlgorithm  ExtendedErrorCode = 1
+	ExtendedErrorUnsupportedDSDigestType     ExtendedErrorCode = 2
+	ExtendedErrorStaleAnswer                 ExtendedErrorCode = 3
+	ExtendedErrorForgedAnswer                ExtendedErrorCode = 4
+	ExtendedErrorDNSSECIndeterminate         ExtendedErrorCode = 5
+	ExtendedErrorDNSSECBogus                 ExtendedErrorCode = 6
+	ExtendedErrorSignatureExpired            ExtendedErrorCode = 7
+	ExtendedErrorSignatureNotYetValid        ExtendedErrorCode = 8
+	ExtendedErrorDNSKEYMissing               ExtendedErrorCode = 9
+	ExtendedErrorRRSIGsMissing               ExtendedErrorCode = 10
+	ExtendedErrorNoZoneKeyBitSet             ExtendedErrorCode = 11
+	ExtendedErrorNSECMissing                 ExtendedErrorCode = 12
+	ExtendedErrorCachedError                 ExtendedErrorCode = 13
+	ExtendedErrorNotReady                    ExtendedErrorCode = 14
+	ExtendedErrorBlocked                     ExtendedErrorCode = 15
+	ExtendedErrorCensored                    ExtendedErrorCode = 16
+	ExtendedErrorFiltered                    ExtendedErrorCode = 17
+	ExtendedErrorProhibited                  ExtendedErrorCode = 18
+	ExtendedErrorStaleNXDomainAnswer         ExtendedErrorCode = 19
+	ExtendedErrorNotAuthoritative            ExtendedErrorCode = 20
+	ExtendedErrorNotSupported                ExtendedErrorCode = 21
+	ExtendedErrorNoReachableAuthority        ExtendedErrorCode = 22
+	ExtendedErrorNetworkError                ExtendedErrorCode = 23
+	ExtendedErrorInvalidData                 ExtendedErrorCode = 24
+	ExtendedErrorSignatureExpiredBeforeValid ExtendedErrorCode = 25
+	ExtendedErrorTooEarly                    ExtendedErrorCode = 26
+	ExtendedErrorUnsupportedNSEC3Iterations  ExtendedErrorCode = 27
+)
+
+// ExtendedErrorCodeStrings holds the registered names of the
+// ExtendedErrorCode constants
+var ExtendedErrorCodeStrings = map[ExtendedErrorCode]string{
+	ExtendedErrorOther:                       "Other Error",
+	ExtendedErrorUnsupportedDNSKEYAlgorithm:  "Unsupported DNSKEY Algorithm",
+	ExtendedErrorUnsupportedDSDigestType:     "Unsupported DS Digest Type",
+	ExtendedErrorStaleAnswer:                 "Stale Answer",
+	ExtendedErrorForgedAnswer:                "Forged Answer",
+	ExtendedErrorDNSSECIndeterminate:         "DNSSEC Indeterminate",
+	ExtendedErrorDNSSECBogus:                 "DNSSEC Bogus",
+	ExtendedErrorSignatureExpired:            "Signature Expired",
+	ExtendedErrorSignatureNotYetValid:        "Signature Not Yet Valid",
+	ExtendedErrorDNSKEYMissing:               "DNSKEY Missing",
+	ExtendedErrorRRSIGsMissing:               "RRSIGs Missing",
+	ExtendedErrorNoZoneKeyBitSet:             "No Zone Key Bit Set",
+	ExtendedErrorNSECMissing:                 "NSEC Missing",
+	ExtendedErrorCachedError:                 "Cached Error",
+	ExtendedErrorNotReady:                    "Not Ready",
+	ExtendedErrorBlocked:                     "Blocked",
+	ExtendedErrorCensored:                    "Censored",
+	ExtendedErrorFiltered:                    "Filtered",
+	ExtendedErrorProhibited:                  "Prohibited",
+	ExtendedErrorStaleNXDomainAnswer:         "Stale NXDomain Answer",
+	ExtendedErrorNotAuthoritative:            "Not Authoritative",
+	ExtendedErrorNotSupported:                "Not Supported",
+	ExtendedErrorNoReachableAuthority:        "No Reachable Authority",
+	ExtendedErrorNetworkError:                "Network Error",
+	ExtendedErrorInvalidData:                 "Invalid Data",
+	ExtendedErrorSignatureExpiredBeforeValid: "Signature Expired before Valid",
+	ExtendedErrorTooEarly:                    "Too Early",
+	ExtendedErrorUnsupportedNSEC3Iterations:  "Unsupported NSEC3 Iterations Value",
+}
+
+// String returns the registered name of the code, or its number for
+// unregistered codes
+func (c ExtendedErrorCode) String() string {
+	if s, ok := ExtendedErrorCodeStrings[c]; ok {
+		return s
+	}
+	return fmt.Sprintf("%d", uint16(c))
+}
+
+// EDNSExtendedError holds an extended DNS error explaining the response code
+// of a response (RFC 8914). ExtraText is UTF-8 text meant for humans.
+type EDNSExtendedError struct {
+	InfoCode  ExtendedErrorCode
+	ExtraText string
+}
+
+// Code returns the option code of the option
+func (*EDNSExtendedError) Code() uint16 { return OptionCodeExtendedError }
+
+// Parse implements parsing of the option data
+func (e *EDNSExtendedError) Parse(b []byte) error {
+	if len(b) < 2 {
+		return fmt.Errorf("invalid extended error length: %d", len(b))
+	}
+	e.InfoCode = ExtendedErrorCode(binary.BigEndian.Uint16(b))
+	e.ExtraText = string(b[2:])
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSExtendedError) Build(buf *bytes.Buffer) error {
+	binary.Write(buf, binary.BigEndian, e.InfoCode)
+	buf.WriteString(e.ExtraText)
+	return nil
+}
+
+// AddExtendedError adds an extended DNS error to m, which is typically a reply
+// created by ReplyTo. A message may hold several errors, and an OPT record is
+// added if m has none. Extended errors should only be sent in replies to
+// queries with EDNS.
+func (m *Message) AddExtendedError(code ExtendedErrorCode, extraText string) {
+	m.AddOption(&EDNSExtendedError{InfoCode: code, ExtraText: extraText})
+}
+
+// ExtendedErrors returns the extended DNS errors of m in the order they were
+// sent
+func (m *Message) ExtendedErrors() []*EDNSExtendedError {
+	opt := m.Opt()
+	if opt == nil {
+		return nil
+	}
+	var errs []*EDNSExtendedError
+	for _, o := range opt.Options {
+		if e, ok := o.(*EDNSExtendedError); ok {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
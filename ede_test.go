@@ -0,0 +1,74 @@
+package dns
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtendedErrorCode_String(t *testing.T) {
+	tests := []struct {
+		code ExtendedErrorCode
+		want string
+	}{
+		{code: ExtendedErrorStaleAnswer, want: "Stale Answer"},
+		{code: ExtendedErrorDNSSECBogus, want: "DNSSEC Bogus"},
+		{code: 49152, want: "49152"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.code.String(); got != tt.want {
+				t.Errorf("ExtendedErrorCode.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage_ExtendedErrors(t *testing.T) {
+	query := testQuery()
+	query.Additional = []Record{*DefaultOpt(1232)}
+	reply := ReplyTo(query)
+	if errs := reply.ExtendedErrors(); errs != nil {
+		t.Fatalf("Message.ExtendedErrors() = %v, want none", errs)
+	}
+	reply.RCode = RCodeServFail
+	reply.AddExtendedError(ExtendedErrorDNSSECBogus, "")
+	reply.AddExtendedError(ExtendedErrorSignatureExpired, "RRSIG of example.com expired")
+
+	b, err := reply.Pack()
+	if err != nil {
+		t.Fatalf("Message.Pack() error = %v", err)
+	}
+	resp, err := Unpack(b)
+	if err != nil {
+		t.Fatalf("Unpack() error = %v", err)
+	}
+	want := []*EDNSExtendedError{
+		{InfoCode: ExtendedErrorDNSSECBogus},
+		{InfoCode: ExtendedErrorSignatureExpired, ExtraText: "RRSIG of example.com expired"},
+	}
+	if got := resp.ExtendedErrors(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Message.ExtendedErrors() = %+v, want %+v", got, want)
+	}
+}
+
+func TestOptionString_ExtendedError(t *testing.T) {
+	tests := []struct {
+		name   string
+		option *EDNSExtendedError
+		want   string
+	}{
+		{name: "Registered code", option: &EDNSExtendedError{InfoCode: ExtendedErrorStaleAnswer},
+			want: "EDE: 3 (Stale Answer)"},
+		{name: "Extra text", option: &EDNSExtendedError{InfoCode: ExtendedErrorBlocked,
+			ExtraText: "ads"}, want: `EDE: 15 (Blocked): "ads"`},
+		{name: "Unregistered code", option: &EDNSExtendedError{InfoCode: 49152},
+			want: "EDE: 49152"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := optionString(tt.option, FormatOptions{}); got != tt.want {
+				t.Errorf("optionString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
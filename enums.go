@@ -1,5 +1,7 @@
 package dns
 
+import "strconv"
+
 type (
 	// Type is just a uint16
 	Type uint16
@@ -8,6 +10,10 @@ type (
 )
 
 const (
+	// OptCd describes if checking is disabled (RFC 4035)
+	OptCd = 0x10
+	// OptAd describes if the data is authenticated (RFC 4035)
+	OptAd = 0x20
 	// OptRa describes if recursion is available
 	OptRa = 0x80
 	// OptRd describes if recursion is desired
@@ -20,11 +26,95 @@ const (
 	OptQr = 0x8000
 )
 
+// List of DNS opcodes
+const (
+	OpCodeQuery  = 0
+	OpCodeIQuery = 1
+	OpCodeStatus = 2
+	OpCodeNotify = 4
+	OpCodeUpdate = 5
+	OpCodeDSO    = 6
+)
+
+// OpCodeStrings holds name mapping for DNS opcodes
+var OpCodeStrings = map[uint8]string{
+	OpCodeQuery:  "QUERY",
+	OpCodeIQuery: "IQUERY",
+	OpCodeStatus: "STATUS",
+	OpCodeNotify: "NOTIFY",
+	OpCodeUpdate: "UPDATE",
+	OpCodeDSO:    "DSO",
+}
+
+// List of DNS response codes. Codes above 15 are extended response codes
+// carried partly in the OPT record.
+const (
+	RCodeNoError   = 0
+	RCodeFormErr   = 1
+	RCodeServFail  = 2
+	RCodeNXDomain  = 3
+	RCodeNotImp    = 4
+	RCodeRefused   = 5
+	RCodeYXDomain  = 6
+	RCodeYXRRSet   = 7
+	RCodeNXRRSet   = 8
+	RCodeNotAuth   = 9
+	RCodeNotZone   = 10
+	RCodeDSOTypeNI = 11
+	RCodeBadVers   = 16
+	RCodeBadSig    = 16 // Shares its value with BADVERS, used in TSIG records
+	RCodeBadKey    = 17
+	RCodeBadTime   = 18
+	RCodeBadMode   = 19
+	RCodeBadName   = 20
+	RCodeBadAlg    = 21
+	RCodeBadTrunc  = 22
+	RCodeBadCookie = 23
+)
+
+// RCodeStrings holds name mapping for DNS response codes
+var RCodeStrings = map[uint16]string{
+	RCodeNoError:   "NOERROR",
+	RCodeFormErr:   "FORMERR",
+	RCodeServFail:  "SERVFAIL",
+	RCodeNXDomain:  "NXDOMAIN",
+	RCodeNotImp:    "NOTIMP",
+	RCodeRefused:   "REFUSED",
+	RCodeYXDomain:  "YXDOMAIN",
+	RCodeYXRRSet:   "YXRRSET",
+	RCodeNXRRSet:   "NXRRSET",
+	RCodeNotAuth:   "NOTAUTH",
+	RCodeNotZone:   "NOTZONE",
+	RCodeDSOTypeNI: "DSOTYPENI",
+	RCodeBadVers:   "BADVERS",
+	RCodeBadKey:    "BADKEY",
+	RCodeBadTime:   "BADTIME",
+	RCodeBadMode:   "BADMODE",
+	RCodeBadName:   "BADNAME",
+	RCodeBadAlg:    "BADALG",
+	RCodeBadTrunc:  "BADTRUNC",
+	RCodeBadCookie: "BADCOOKIE",
+}
+
 const (
 	// IN is the standard class
 	IN Class = 1
+	// CS is the obsolete CSNET class
+	CS Class = 2
+	// CH is the Chaos class
+	CH Class = 3
+	// HS is the Hesiod class
+	HS Class = 4
 )
 
+// ClassStrings holds name mapping for DNS class constants
+var ClassStrings = map[Class]string{
+	IN: "IN",
+	CS: "CS",
+	CH: "CH",
+	HS: "HS",
+}
+
 // List of all DNS type constants
 const (
 	A          Type = 1
@@ -132,3 +222,21 @@ var RRTypeStrings = map[Type]string{
 	TA:         "TA",
 	DLV:        "DLV",
 }
+
+// String returns the mnemonic of the type, or the generic TYPEnnn form of
+// RFC 3597 for types without one
+func (t Type) String() string {
+	if s, ok := RRTypeStrings[t]; ok {
+		return s
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
+
+// String returns the mnemonic of the class, or the generic CLASSnnn form of
+// RFC 3597 for classes without one
+func (c Class) String() string {
+	if s, ok := ClassStrings[c]; ok {
+		return s
+	}
+	return "CLASS" + strconv.Itoa(int(c))
+}
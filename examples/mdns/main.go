@@ -2,7 +2,6 @@ package main
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"net"
 	"os"
@@ -49,13 +48,9 @@ func main() {
 		message, err := dns.ParseMessage(buf)
 		if err != nil {
 			fmt.Println(err.Error())
+			continue
 		}
 		fmt.Printf("Read %d bytes from %s%%%s containing: \n%s\n", n,
-			src.IP.String(), src.Zone, prettyPrint(message))
+			src.IP.String(), src.Zone, message.FormatWith(dns.FormatOptions{Unicode: true}))
 	}
 }
-
-func prettyPrint(i any) string {
-	s, _ := json.MarshalIndent(i, "", "  ")
-	return string(s)
-}
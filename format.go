@@ -0,0 +1,166 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// FormatOptions controls the presentation of messages and records for display
+type FormatOptions struct {
+	// Unicode shows the A-labels (xn--) of internationalized names as Unicode
+	Unicode bool
+}
+
+// rdataFormatter is implemented by RDATA holding names, so the names are
+// presented with the options of FormatWith
+type rdataFormatter interface {
+	format(opts FormatOptions) string
+}
+
+// name returns the presentation format of name, with A-labels shown as Unicode
+// if requested. Only names are converted, never text or opaque data.
+func (opts FormatOptions) name(name string) string {
+	if !opts.Unicode {
+		return nameString(name)
+	}
+	labels := nameLabels(name)
+	for i, label := range labels {
+		if u := unicodeLabel(label); u != label {
+			labels[i] = u
+		} else {
+			labels[i] = labelString(label)
+		}
+	}
+	return strings.Join(labels, ".") + "."
+}
+
+// FormatWith returns the record like String, with the display options of opts
+// applied
+func (r Record) FormatWith(opts FormatOptions) string {
+	return fmt.Sprintf("%s\t%d\t%s\t%s\t%s", opts.name(r.Name), r.TTL,
+		Class(r.Class), r.Type, rdataString(&r, opts))
+}
+
+// String returns the message in the format of dig, with a header summary, the
+// EDNS pseudo section and all records in presentation format
+func (m *Message) String() string {
+	return m.FormatWith(FormatOptions{})
+}
+
+// FormatWith returns the message like String, with the display options of
+// opts applied
+func (m *Message) FormatWith(opts FormatOptions) string {
+	var b strings.Builder
+	opt := m.Opt()
+	fmt.Fprintf(&b, ";; ->>HEADER<<- opcode: %s, status: %s, id: %d\n",
+		opCodeString(m.OPCode), rCodeString(m.ExtendedRCode()), m.ID)
+
+	var flags []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{m.QR, "qr"}, {m.AA, "aa"}, {m.TC, "tc"}, {m.RD, "rd"},
+		{m.RA, "ra"}, {m.AD, "ad"}, {m.CD, "cd"},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	fmt.Fprintf(&b, ";; flags: %s; QUERY: %d, ANSWER: %d, AUTHORITY: %d, ADDITIONAL: %d\n",
+		strings.Join(flags, " "), len(m.Questions), len(m.Answers), len(m.Nameservers),
+		len(m.Additional))
+
+	if opt != nil {
+		b.WriteString("\n;; OPT PSEUDOSECTION:\n")
+		ednsFlags := ""
+		if opt.DNSSec {
+			ednsFlags = " do"
+		}
+		fmt.Fprintf(&b, "; EDNS: version: %d, flags:%s; udp: %d\n",
+			opt.EDNSVersion, ednsFlags, opt.UDPSize)
+		for _, option := range opt.Options {
+			fmt.Fprintf(&b, "; %s\n", optionString(option, opts))
+		}
+	}
+
+	if len(m.Questions) > 0 {
+		b.WriteString("\n;; QUESTION SECTION:\n")
+		for _, q := range m.Questions {
+			fmt.Fprintf(&b, ";%s\n", q.format(opts))
+		}
+	}
+	for _, section := range []struct {
+		name    string
+		records []Record
+	}{
+		{"ANSWER", m.Answers},
+		{"AUTHORITY", m.Nameservers},
+		{"ADDITIONAL", m.Additional},
+	} {
+		var lines []string
+		for _, r := range section.records {
+			if r.Type != OPT {
+				lines = append(lines, r.FormatWith(opts))
+			}
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "\n;; %s SECTION:\n%s\n", section.name, strings.Join(lines, "\n"))
+		}
+	}
+	return b.String()
+}
+
+func opCodeString(op uint8) string {
+	if s, ok := OpCodeStrings[op]; ok {
+		return s
+	}
+	return fmt.Sprintf("OPCODE%d", op)
+}
+
+func rCodeString(rcode uint16) string {
+	if s, ok := RCodeStrings[rcode]; ok {
+		return s
+	}
+	return fmt.Sprintf("RCODE%d", rcode)
+}
+
+// optionString returns a readable form of an EDNS option
+func optionString(option EDNSOption, opts FormatOptions) string {
+	switch o := option.(type) {
+	case *EDNSNSID:
+		return fmt.Sprintf("NSID: %s (%q)", hex.EncodeToString(o.ID), o.ID)
+	case *EDNSClientSubnet:
+		return fmt.Sprintf("CLIENT-SUBNET: %s/%d/%d", o.Address, o.SourcePrefix, o.ScopePrefix)
+	case *EDNSExpire:
+		if o.Empty {
+			return "EXPIRE:"
+		}
+		return fmt.Sprintf("EXPIRE: %d", o.Expire)
+	case *EDNSCookie:
+		return "COOKIE: " + hex.EncodeToString(o.Client[:]) + hex.EncodeToString(o.Server)
+	case *EDNSTCPKeepalive:
+		if o.Empty {
+			return "TCP-KEEPALIVE:"
+		}
+		return fmt.Sprintf("TCP-KEEPALIVE: %d.%d secs", o.Timeout/10, o.Timeout%10)
+	case *EDNSPadding:
+		return fmt.Sprintf("PADDING: (%d bytes)", o.Length)
+	case *EDNSChain:
+		return "CHAIN: " + opts.name(o.ClosestTrustPoint)
+	case *EDNSExtendedError:
+		s := fmt.Sprintf("EDE: %d", o.InfoCode)
+		if name, ok := ExtendedErrorCodeStrings[o.InfoCode]; ok {
+			s += " (" + name + ")"
+		}
+		if o.ExtraText != "" {
+			s += fmt.Sprintf(": %q", o.ExtraText)
+		}
+		return s
+	}
+	var data bytes.Buffer
+	option.Build(&data)
+	return fmt.Sprintf("OPT=%d: %s", option.Code(), hex.EncodeToString(data.Bytes()))
+}
@@ -0,0 +1,90 @@
+package dns
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestMessage_String(t *testing.T) {
+	opt := DefaultOpt(1232)
+	opt.Data.(*Opt).DNSSec = true
+	opt.Data.(*Opt).Options = []EDNSOption{
+		&EDNSNSID{ID: []byte("ns1")},
+		&EDNSClientSubnet{Family: FamilyIPv4, SourcePrefix: 24,
+			Address: netip.MustParseAddr("192.0.2.0")},
+		&EDNSCookie{Client: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}},
+		&EDNSExtendedError{InfoCode: 18, ExtraText: "blocked"},
+		&EDNSOpaque{OptionCode: 65001, Data: []byte{0xab}},
+	}
+	tests := []struct {
+		name string
+		m    *Message
+		want string
+	}{
+		{
+			name: "Response with EDNS",
+			m: &Message{
+				ID: 4242, QR: true, RD: true, RA: true, AD: true,
+				Questions: []Question{{Domain: "example.com", Type: A, Class: IN}},
+				Answers: []Record{{Name: "example.com", Type: A, Class: uint16(IN), TTL: 300,
+					Data: &IPv4{netip.MustParseAddr("192.0.2.1")}}},
+				Additional: []Record{*opt},
+			},
+			want: `;; ->>HEADER<<- opcode: QUERY, status: NOERROR, id: 4242
+;; flags: qr rd ra ad; QUERY: 1, ANSWER: 1, AUTHORITY: 0, ADDITIONAL: 1
+
+;; OPT PSEUDOSECTION:
+; EDNS: version: 0, flags: do; udp: 1232
+; NSID: 6e7331 ("ns1")
+; CLIENT-SUBNET: 192.0.2.0/24/0
+; COOKIE: 0102030405060708
+; EDE: 18 (Prohibited): "blocked"
+; OPT=65001: ab
+
+;; QUESTION SECTION:
+;example.com.	IN	A
+
+;; ANSWER SECTION:
+example.com.	300	IN	A	192.0.2.1
+`,
+		},
+		{
+			name: "NXDOMAIN with authority",
+			m: &Message{
+				ID: 1, QR: true, AA: true, OPCode: OpCodeQuery, RCode: RCodeNXDomain,
+				Questions: []Question{{Domain: "nope.example.com", Type: AAAA, Class: IN}},
+				Nameservers: []Record{{Name: "example.com", Type: SOA, Class: uint16(IN), TTL: 300,
+					Data: &Soa{MName: "ns1.example.com", RName: "hostmaster.example.com", Serial: 1}}},
+			},
+			want: `;; ->>HEADER<<- opcode: QUERY, status: NXDOMAIN, id: 1
+;; flags: qr aa; QUERY: 1, ANSWER: 0, AUTHORITY: 1, ADDITIONAL: 0
+
+;; QUESTION SECTION:
+;nope.example.com.	IN	AAAA
+
+;; AUTHORITY SECTION:
+example.com.	300	IN	SOA	ns1.example.com. hostmaster.example.com. 1 0 0 0 0
+`,
+		},
+		{
+			name: "Unknown opcode and extended rcode",
+			m: &Message{
+				ID: 7, OPCode: 9, RCode: 0,
+				Additional: []Record{{Type: OPT, Data: &Opt{UDPSize: 512, RCode: 1}}},
+			},
+			want: `;; ->>HEADER<<- opcode: OPCODE9, status: BADVERS, id: 7
+;; flags: ; QUERY: 0, ANSWER: 0, AUTHORITY: 0, ADDITIONAL: 1
+
+;; OPT PSEUDOSECTION:
+; EDNS: version: 0, flags:; udp: 512
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.String(); got != tt.want {
+				t.Errorf("Message.String() = \n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
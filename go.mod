@@ -4,4 +4,7 @@ go 1.23.0
 
 require golang.org/x/net v0.38.0
 
-require golang.org/x/sys v0.31.0 // indirect
+require (
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+)
@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// aLabelPrefix marks labels holding a punycode encoded Unicode label
+const aLabelPrefix = "xn--"
+
+// ToASCII converts the Unicode labels of name to A-labels (IDNA 2008, RFC
+// 5891), as needed for Question.Domain and Record.Name. Unicode labels are
+// mapped as for a lookup (UTS #46), so they are also lowercased, while ASCII
+// labels are kept unchanged.
+func ToASCII(name string) (string, error) {
+	labels := nameLabels(name)
+	for i, label := range labels {
+		if isASCII(label) {
+			continue
+		}
+		a, err := idna.Lookup.ToASCII(label)
+		if err != nil {
+			return "", fmt.Errorf("invalid internationalized label %q: %w", label, err)
+		}
+		labels[i] = a
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// ToUnicode converts the A-labels of name to Unicode for display. Labels that
+// are not valid A-labels are kept unchanged. The result is not in the escaped
+// form of names used by this package, so it should not be built into messages.
+func ToUnicode(name string) string {
+	labels := nameLabels(name)
+	for i, label := range labels {
+		labels[i] = unicodeLabel(label)
+	}
+	return strings.Join(labels, ".")
+}
+
+// unicodeLabel returns the Unicode form of an A-label, or label itself if it
+// is not a valid A-label
+func unicodeLabel(label string) string {
+	if len(label) <= len(aLabelPrefix) ||
+		!strings.EqualFold(label[:len(aLabelPrefix)], aLabelPrefix) {
+		return label
+	}
+	u, err := idna.Lookup.ToUnicode(label)
+	if err != nil || isASCII(u) {
+		return label
+	}
+	return u
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToASCII(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{name: "ASCII", in: "www.Example.com", want: "www.Example.com"},
+		{name: "Unicode label", in: "bücher.example", want: "xn--bcher-kva.example"},
+		{name: "Mapped to lower case", in: "BÜCHER.example", want: "xn--bcher-kva.example"},
+		{name: "Disallowed characters", in: "Drucker für Büro._ipp._tcp.local", wantErr: true},
+		{name: "Escaped label kept", in: `a\.b.bücher.example`, want: `a\.b.xn--bcher-kva.example`},
+		{name: "Root", in: "", want: ""},
+		{name: "Invalid", in: "a‍.example", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToASCII(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToASCII() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ToASCII() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToUnicode(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "xn--bcher-kva.example", want: "bücher.example"},
+		{in: "www.XN--BCHER-KVA.example", want: "www.bücher.example"},
+		{in: "xn--invalid-.example", want: "xn--invalid-.example"},
+		{in: `a\.b.example`, want: `a\.b.example`},
+		{in: "xn--", want: "xn--"},
+	}
+	for _, tt := range tests {
+		if got := ToUnicode(tt.in); got != tt.want {
+			t.Errorf("ToUnicode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRecord_FormatWith(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Record
+		want string
+	}{
+		{
+			name: "Owner and target",
+			r: Record{Name: "xn--bcher-kva.example", Type: CNAME, Class: uint16(IN), TTL: 300,
+				Data: &CName{Name: "www.xn--bcher-kva.example"}},
+			want: "bücher.example.\t300\tIN\tCNAME\twww.bücher.example.",
+		},
+		{
+			name: "Text",
+			r: Record{Name: "example", Type: TXT, Class: uint16(IN), TTL: 300,
+				Data: &Txt{Data: []string{"xn--bcher-kva.example"}}},
+			want: "example.\t300\tIN\tTXT\t\"xn--bcher-kva.example\"",
+		},
+		{
+			name: "Opaque SVCB param",
+			r: Record{Name: "xn--bcher-kva.example", Type: SVCB, Class: uint16(IN), TTL: 300,
+				Data: &Svcb{Priority: 1, Target: "xn--bcher-kva.example",
+					Params: []SvcParam{&SvcOpaque{KeyCode: 65000, Value: []byte("xn--bcher-kva")}}}},
+			want: "bücher.example.\t300\tIN\tSVCB\t1 bücher.example. key65000=\"xn--bcher-kva\"",
+		},
+		{
+			name: "Escaped label",
+			r: Record{Name: `a\.xn--bcher-kva.example`, Type: CNAME, Class: uint16(IN), TTL: 300,
+				Data: &CName{Name: "axn--bcher-kva.example"}},
+			want: "a\\.xn--bcher-kva.example.\t300\tIN\tCNAME\taxn--bcher-kva.example.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.FormatWith(FormatOptions{Unicode: true}); got != tt.want {
+				t.Errorf("Record.FormatWith() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage_FormatWith(t *testing.T) {
+	m := testAnswer(&Message{Questions: []Question{{Domain: "xn--bcher-kva.example", Type: A,
+		Class: IN}}}, "192.0.2.1")
+	m.AddOption(&EDNSChain{ClosestTrustPoint: "xn--bcher-kva.example"})
+	m.AddExtendedError(ExtendedErrorBlocked, "xn--bcher-kva")
+	got := m.FormatWith(FormatOptions{Unicode: true})
+	if !strings.Contains(got, ";bücher.example.\tIN\tA") ||
+		!strings.Contains(got, "bücher.example.\t60\tIN\tA\t192.0.2.1") ||
+		!strings.Contains(got, "; CHAIN: bücher.example.\n") ||
+		!strings.Contains(got, `; EDE: 15 (Blocked): "xn--bcher-kva"`) {
+		t.Errorf("Message.FormatWith() =\n%s\nwant Unicode names", got)
+	}
+	if got := m.FormatWith(FormatOptions{}); got != m.String() {
+		t.Errorf("Message.FormatWith() without options =\n%s\nwant\n%s", got, m.String())
+	}
+	got = m.Answers[0].FormatWith(FormatOptions{Unicode: true})
+	if !strings.HasPrefix(got, "bücher.example.") {
+		t.Errorf("Record.FormatWith() = %q, want Unicode owner name", got)
+	}
+}
@@ -20,6 +20,8 @@ type Message struct {
 	TC          bool
 	RD          bool
 	RA          bool
+	AD          bool
+	CD          bool
 	RCode       uint8
 	qdcount     uint16
 	ancount     uint16
@@ -100,6 +102,8 @@ func (m *Message) BuildHeader(buf *bytes.Buffer) error {
 func (m *Message) parseOpts(opts uint16) {
 	m.RCode = uint8(opts & 0xf)
 	m.OPCode = uint8((opts >> 11) & 0xf)
+	m.CD = opts&OptCd == OptCd
+	m.AD = opts&OptAd == OptAd
 	m.RA = opts&OptRa == OptRa
 	m.RD = opts&OptRd == OptRd
 	m.TC = opts&OptTc == OptTc
@@ -111,6 +115,8 @@ func (m *Message) buildOpts() uint16 {
 	var opts uint16
 	opts |= (uint16(m.RCode) & 0x000f)
 	opts |= (uint16(m.OPCode) & 0x000f) << 11
+	opts |= opt(m.CD, OptCd)
+	opts |= opt(m.AD, OptAd)
 	opts |= opt(m.RA, OptRa)
 	opts |= opt(m.RD, OptRd)
 	opts |= opt(m.TC, OptTc)
@@ -130,13 +136,15 @@ func opt(flag bool, bit uint16) uint16 {
 // buf
 func ParseMessage(buf *bytes.Buffer) (*Message, error) {
 	m := &Message{}
+	// Pointers are resolved by reading the names they point to in the message,
+	// which starts at the unread part of buf
+	domains := &Domains{msg: buf.Bytes()}
 	var err error
 	err = m.ParseHeader(buf)
 	if err != nil {
 		return m, err
 	}
 	ptr := HdrLength
-	domains := &Domains{parsePtr: map[int]string{}, buildPtr: map[string]int{}}
 	ptr, err = m.parseQuestions(buf, domains, ptr)
 	if err != nil {
 		return m, fmt.Errorf("unable to parse questions: %w", err)
@@ -249,3 +257,39 @@ func ReplyTo(other *Message) *Message {
 	}
 	return m
 }
+
+// Opt returns the OPT pseudo record of the message, or nil if the message has
+// no EDNS support
+func (m *Message) Opt() *Opt {
+	for _, r := range m.Additional {
+		if opt, ok := r.Data.(*Opt); ok {
+			return opt
+		}
+	}
+	return nil
+}
+
+// ExtendedRCode returns the 12 bit response code of the message, combining the
+// 4 bits of the header with the upper 8 bits in the OPT record (RFC 6891
+// section 6.1.3)
+func (m *Message) ExtendedRCode() uint16 {
+	rcode := uint16(m.RCode & 0xf)
+	if opt := m.Opt(); opt != nil {
+		rcode |= uint16(opt.RCode) << 4
+	}
+	return rcode
+}
+
+// SetExtendedRCode sets the 12 bit response code of the message in both the
+// header and the OPT record. An OPT record is added for codes above 15, which
+// can only be sent with EDNS.
+func (m *Message) SetExtendedRCode(rcode uint16) {
+	m.RCode = uint8(rcode & 0xf)
+	opt := m.Opt()
+	if opt == nil && rcode > 0xf {
+		opt = m.addOpt()
+	}
+	if opt != nil {
+		opt.RCode = uint8(rcode >> 4)
+	}
+}
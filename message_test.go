@@ -17,6 +17,8 @@ func TestMessage_ParseHeader(t *testing.T) {
 		tc        bool
 		rd        bool
 		ra        bool
+		ad        bool
+		cd        bool
 		rcode     uint8
 		qdcount   uint16
 		ancount   uint16
@@ -81,6 +83,22 @@ func TestMessage_ParseHeader(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "DNSSEC response header",
+			wantFields: fields{
+				id:      0x3028,
+				qr:      true,
+				rd:      true,
+				ra:      true,
+				ad:      true,
+				cd:      true,
+				rcode:   3,
+				qdcount: 1,
+			},
+			args: args{
+				buf: []byte("\x30\x28\x81\xb3\x00\x01\x00\x00\x00\x00\x00\x00"),
+			},
+		},
 		{
 			name:       "Missing fields",
 			wantFields: fields{},
@@ -101,6 +119,8 @@ func TestMessage_ParseHeader(t *testing.T) {
 				TC:        tt.wantFields.tc,
 				RD:        tt.wantFields.rd,
 				RA:        tt.wantFields.ra,
+				AD:        tt.wantFields.ad,
+				CD:        tt.wantFields.cd,
 				RCode:     tt.wantFields.rcode,
 				qdcount:   tt.wantFields.qdcount,
 				ancount:   tt.wantFields.ancount,
@@ -129,6 +149,8 @@ func TestMessage_BuildHeader(t *testing.T) {
 		tc          bool
 		rd          bool
 		ra          bool
+		ad          bool
+		cd          bool
 		rcode       uint8
 		qdcount     uint16
 		ancount     uint16
@@ -163,6 +185,20 @@ func TestMessage_BuildHeader(t *testing.T) {
 			},
 			want: []byte("\x19\x9f\x01\x00\x00\x01\x00\x00\x00\x00\x00\x00"),
 		},
+		{
+			name: "DNSSEC response header",
+			fields: fields{
+				id:      0x3028,
+				qr:      true,
+				rd:      true,
+				ra:      true,
+				ad:      true,
+				cd:      true,
+				rcode:   3,
+				qdcount: 1,
+			},
+			want: []byte("\x30\x28\x81\xb3\x00\x01\x00\x00\x00\x00\x00\x00"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -174,6 +210,8 @@ func TestMessage_BuildHeader(t *testing.T) {
 				TC:          tt.fields.tc,
 				RD:          tt.fields.rd,
 				RA:          tt.fields.ra,
+				AD:          tt.fields.ad,
+				CD:          tt.fields.cd,
 				RCode:       tt.fields.rcode,
 				qdcount:     tt.fields.qdcount,
 				ancount:     tt.fields.ancount,
@@ -228,6 +266,42 @@ func TestParseMessage(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name: "Negative response with SOA authority record",
+			buf: []byte("\x00\x1d\x81\x83\x00\x01\x00\x00\x00\x01\x00\x00\x06\x67\x6f\x6c\x61\x6e\x67\x03\x6f\x72\x67\x00\x00\x1c\x00\x01" +
+				"\xc0\x13\x00\x06\x00\x01\x00\x00\x03\x84\x00\x20\x02ns\xc0\x13\x04host\xc0\x13" +
+				"\x00\x00\x00\x01\x00\x00\x07\x08\x00\x00\x03\x84\x00\x09\x3a\x80\x00\x00\x03\x84"),
+			want: &Message{
+				ID:      0x001d,
+				QR:      true,
+				RD:      true,
+				RA:      true,
+				RCode:   3,
+				qdcount: 1,
+				nscount: 1,
+				Questions: []Question{{
+					Domain: "golang.org",
+					Type:   AAAA,
+					Class:  1,
+				}},
+				Nameservers: []Record{{
+					TTL:    900,
+					Class:  1,
+					Length: 32,
+					Type:   SOA,
+					Name:   "org",
+					Data: &Soa{
+						MName:   "ns.org",
+						RName:   "host.org",
+						Serial:  1,
+						Refresh: 1800,
+						Retry:   900,
+						Expire:  604800,
+						Minimum: 900,
+					},
+				}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -252,6 +326,8 @@ func TestMessage_Build(t *testing.T) {
 		tc          bool
 		rd          bool
 		ra          bool
+		ad          bool
+		cd          bool
 		rcode       uint8
 		qdcount     uint16
 		ancount     uint16
@@ -317,6 +393,39 @@ func TestMessage_Build(t *testing.T) {
 				}},
 			},
 		},
+		{
+			name: "Negative response with SOA",
+			want: []byte("\x00\x1d\x81\x83\x00\x01\x00\x00\x00\x01\x00\x00\x06\x67\x6f\x6c\x61\x6e\x67\x03\x6f\x72\x67\x00\x00\x1c\x00\x01" +
+				"\xc0\x13\x00\x06\x00\x01\x00\x00\x03\x84\x00\x20\x02ns\xc0\x13\x04host\xc0\x13" +
+				"\x00\x00\x00\x01\x00\x00\x07\x08\x00\x00\x03\x84\x00\x09\x3a\x80\x00\x00\x03\x84"),
+			fields: fields{
+				id:    0x001d,
+				qr:    true,
+				rd:    true,
+				ra:    true,
+				rcode: 3,
+				questions: []Question{{
+					Domain: "golang.org",
+					Type:   AAAA,
+					Class:  1,
+				}},
+				nameservers: []Record{{
+					TTL:   900,
+					Class: 1,
+					Type:  SOA,
+					Name:  "org",
+					Data: &Soa{
+						MName:   "ns.org",
+						RName:   "host.org",
+						Serial:  1,
+						Refresh: 1800,
+						Retry:   900,
+						Expire:  604800,
+						Minimum: 900,
+					},
+				}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -328,6 +437,8 @@ func TestMessage_Build(t *testing.T) {
 				TC:          tt.fields.tc,
 				RD:          tt.fields.rd,
 				RA:          tt.fields.ra,
+				AD:          tt.fields.ad,
+				CD:          tt.fields.cd,
 				RCode:       tt.fields.rcode,
 				qdcount:     tt.fields.qdcount,
 				ancount:     tt.fields.ancount,
@@ -367,3 +478,56 @@ func BenchmarkMessageParsing(b *testing.B) {
 		ParseMessage(bytes.NewBuffer(buf))
 	}
 }
+
+func TestMessage_ExtendedRCode(t *testing.T) {
+	tests := []struct {
+		name      string
+		rcode     uint16
+		withOpt   bool
+		wantRCode uint8
+		wantOpt   bool
+		wantUpper uint8
+	}{
+		{name: "NXDOMAIN without EDNS", rcode: RCodeNXDomain, wantRCode: 3},
+		{name: "NXDOMAIN with EDNS", rcode: RCodeNXDomain, withOpt: true, wantRCode: 3,
+			wantOpt: true},
+		{name: "BADVERS adds OPT", rcode: RCodeBadVers, wantRCode: 0, wantOpt: true,
+			wantUpper: 1},
+		{name: "BADCOOKIE", rcode: RCodeBadCookie, withOpt: true, wantRCode: 7, wantOpt: true,
+			wantUpper: 1},
+		{name: "Largest code", rcode: 0xfff, wantRCode: 0xf, wantOpt: true, wantUpper: 0xff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Message{}
+			if tt.withOpt {
+				m.Additional = []Record{*DefaultOpt(1232)}
+				m.Opt().RCode = 0xab
+			}
+			m.SetExtendedRCode(tt.rcode)
+			if m.RCode != tt.wantRCode {
+				t.Errorf("Message.SetExtendedRCode() header = %d, want %d", m.RCode, tt.wantRCode)
+			}
+			opt := m.Opt()
+			if (opt != nil) != tt.wantOpt {
+				t.Fatalf("Message.SetExtendedRCode() OPT = %v, want OPT %v", opt, tt.wantOpt)
+			}
+			if opt != nil && opt.RCode != tt.wantUpper {
+				t.Errorf("Message.SetExtendedRCode() OPT rcode = %d, want %d", opt.RCode,
+					tt.wantUpper)
+			}
+
+			b, err := m.Pack()
+			if err != nil {
+				t.Fatalf("Message.Pack() error = %v", err)
+			}
+			parsed, err := Unpack(b)
+			if err != nil {
+				t.Fatalf("Unpack() error = %v", err)
+			}
+			if got := parsed.ExtendedRCode(); got != tt.rcode {
+				t.Errorf("Message.ExtendedRCode() = %d, want %d", got, tt.rcode)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Mx implements interface RData
+type Mx struct {
+	Preference    uint16
+	Exchange      string
+	exchangeBytes string
+}
+
+// Parse implements MX parsing for interface RData
+func (m *Mx) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
+	if err := binary.Read(buf, binary.BigEndian, &m.Preference); err != nil {
+		return err
+	}
+	name, err := ParseName(buf, ptr+2, domains)
+	if err != nil {
+		return fmt.Errorf("unable to parse MX: %w", err)
+	}
+	m.Exchange = name
+	return nil
+}
+
+// Build implements MX building for interface RData
+func (m *Mx) Build(buf *bytes.Buffer, domains *Domains) error {
+	if err := binary.Write(buf, binary.BigEndian, m.Preference); err != nil {
+		return err
+	}
+	domains.SetBuild(buf.Len(), m.Exchange)
+	buf.WriteString(m.exchangeBytes)
+	return nil
+}
+
+// PreBuild step, building name and adding full record
+func (m *Mx) PreBuild(_ *Record, domains *Domains) (int, error) {
+	var err error
+	if m.exchangeBytes, err = buildName(m.Exchange, domains); err != nil {
+		return 0, err
+	}
+	return len(m.exchangeBytes) + 2, nil
+}
+
+// TransformName satisfies the interface
+func (*Mx) TransformName(name string) string { return name }
+
+// parseText implements presentation format parsing of MX records
+func (m *Mx) parseText(t *rdataText) error {
+	var err error
+	if m.Preference, err = t.uint16("preference"); err != nil {
+		return err
+	}
+	m.Exchange, err = t.name("exchange")
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (m *Mx) String() string { return m.format(FormatOptions{}) }
+
+func (m *Mx) format(opts FormatOptions) string {
+	return fmt.Sprintf("%d %s", m.Preference, opts.name(m.Exchange))
+}
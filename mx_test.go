@@ -0,0 +1,103 @@
+package dns
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMx_Parse(t *testing.T) {
+	type args struct {
+		buf     []byte
+		ptr     int
+		domains *Domains
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Mx
+		wantErr bool
+	}{
+		{
+			name: "Simple MX record",
+			args: args{
+				buf:     []byte("\x00\x0a\x04mail\x06golang\x03com\x00"),
+				domains: NewDomains(),
+			},
+			want: &Mx{Preference: 10, Exchange: "mail.golang.com"},
+		},
+		{
+			name: "Compressed MX record",
+			args: args{
+				buf:     []byte("\x00\x14\x04mail\xc0\x00"),
+				ptr:     12,
+				domains: &Domains{parsePtr: map[int]string{0: "golang.com"}},
+			},
+			want: &Mx{Preference: 20, Exchange: "mail.golang.com"},
+		},
+		{
+			name: "Missing preference",
+			args: args{
+				buf:     []byte("\x00"),
+				domains: NewDomains(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Mx{}
+			if err := m.Parse(bytes.NewBuffer(tt.args.buf), tt.args.ptr, tt.args.domains); (err != nil) != tt.wantErr {
+				t.Errorf("Mx.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(m, tt.want) {
+				t.Errorf("Mx.Parse() = %+v, want %+v", m, tt.want)
+			}
+			if name, ok := tt.args.domains.GetParse(tt.args.ptr + 2); !tt.wantErr && (!ok || name != tt.want.Exchange) {
+				t.Errorf("Mx.Parse() domains[%d] = %v, want %v", tt.args.ptr+2, name, tt.want.Exchange)
+			}
+		})
+	}
+}
+
+func TestMx_Build(t *testing.T) {
+	tests := []struct {
+		name       string
+		mx         Mx
+		domains    *Domains
+		want       []byte
+		wantLength int
+	}{
+		{
+			name:       "Simple MX record",
+			mx:         Mx{Preference: 10, Exchange: "mail.golang.com"},
+			domains:    NewDomains(),
+			want:       []byte("\x00\x0a\x04mail\x06golang\x03com\x00"),
+			wantLength: 19,
+		},
+		{
+			name:       "Compressed MX record",
+			mx:         Mx{Preference: 20, Exchange: "mail.golang.com"},
+			domains:    &Domains{buildPtr: map[string]int{"golang.com": 12}},
+			want:       []byte("\x00\x14\x04mail\xc0\x0c"),
+			wantLength: 9,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if length, err := tt.mx.PreBuild(&Record{}, tt.domains); err != nil || length != tt.wantLength {
+				t.Errorf("Mx.PreBuild() = %v, %v, want %v", length, err, tt.wantLength)
+				return
+			}
+			if err := tt.mx.Build(buf, tt.domains); err != nil {
+				t.Errorf("Mx.Build() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(buf.Bytes(), tt.want) {
+				t.Errorf("Mx.Build() = %v, want %v", buf.Bytes(), tt.want)
+			}
+		})
+	}
+}
@@ -3,7 +3,12 @@ package dns
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -14,7 +19,22 @@ const (
 	PointerMask = 0x3fff
 )
 
-// ParseName returns a name given a pointer
+// Limits of names on the wire (RFC 1035 section 2.3.4)
+const (
+	maxLabelLength = 63
+	maxNameLength  = 255
+)
+
+// Errors returned for malformed names
+var (
+	ErrPointerLoop  = errors.New("dns: name pointer does not point backwards")
+	ErrNameTooLong  = errors.New("dns: name longer than 255 octets")
+	ErrLabelTooLong = errors.New("dns: label longer than 63 octets")
+)
+
+// ParseName returns a name given a pointer. Compression pointers must point
+// to a name before the current one, so pointers into the name itself or forward
+// in the message are rejected with ErrPointerLoop.
 func ParseName(buf *bytes.Buffer, ptr int, domains *Domains) (string, error) {
 	var name bytes.Buffer
 	length, err := buf.ReadByte()
@@ -28,6 +48,7 @@ func ParseName(buf *bytes.Buffer, ptr int, domains *Domains) (string, error) {
 	}
 
 	newDomain := false
+	wireLength := 1
 
 	for {
 		// Check if name is a pointer to an earlier refereced domain
@@ -37,10 +58,16 @@ func ParseName(buf *bytes.Buffer, ptr int, domains *Domains) (string, error) {
 				return "", err
 			}
 			getPointer := (int(length)<<8 | int(l2)) & PointerMask
+			if getPointer >= ptr {
+				return "", fmt.Errorf("%w: pointer %d in name at offset %d", ErrPointerLoop,
+					getPointer, ptr)
+			}
 			n, ok := domains.GetParse(getPointer)
 			if !ok {
-				return "", fmt.Errorf("name pointer %d points to nothing, full map \n%+v",
-					getPointer, domains.parsePtr)
+				return "", fmt.Errorf("name pointer %d points to nothing", getPointer)
+			}
+			if wireLength+nameWireLength(n)-1 > maxNameLength {
+				return "", ErrNameTooLong
 			}
 			name.WriteString(n)
 			if newDomain {
@@ -48,9 +75,19 @@ func ParseName(buf *bytes.Buffer, ptr int, domains *Domains) (string, error) {
 			}
 			return name.String(), nil
 		}
+		if length > maxLabelLength {
+			return "", fmt.Errorf("%w: length %d", ErrLabelTooLong, length)
+		}
+		wireLength += 1 + int(length)
+		if wireLength > maxNameLength {
+			return "", ErrNameTooLong
+		}
 		newDomain = true
 		n := buf.Next(int(length))
-		name.Write(n)
+		if len(n) < int(length) {
+			return "", io.ErrUnexpectedEOF
+		}
+		name.WriteString(escapeLabel(n))
 		length, err = buf.ReadByte()
 		if err != nil {
 			return "", err
@@ -65,7 +102,176 @@ func ParseName(buf *bytes.Buffer, ptr int, domains *Domains) (string, error) {
 	return name.String(), nil
 }
 
-// BuildName returns a dns encoded name with pointers if possible
+// nameWireLength returns the length of a name on the wire without compression
+func nameWireLength(name string) int {
+	length := 1
+	for _, label := range nameLabels(name) {
+		length += 1 + len(rawLabel(label))
+	}
+	return length
+}
+
+// escapeLabel returns the string form of a label read from the wire. Dots and
+// backslashes are escaped as \. and \\, and bytes that are neither printable
+// ASCII nor part of printable UTF-8 as \DDD (RFC 4343 section 2.1), so the
+// label can be split from its name and built back unchanged.
+func escapeLabel(label []byte) string {
+	if !needsEscape(label) {
+		return string(label)
+	}
+	var b strings.Builder
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if n := printableRune(label[i:]); n > 0 {
+			b.Write(label[i : i+n])
+			i += n - 1
+			continue
+		}
+		switch {
+		case c == '.' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func needsEscape(label []byte) bool {
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if n := printableRune(label[i:]); n > 0 {
+			i += n - 1
+			continue
+		}
+		if c == '.' || c == '\\' || c < ' ' || c > '~' {
+			return true
+		}
+	}
+	return false
+}
+
+// printableRune returns the length of the printable non-ASCII UTF-8 character
+// at the start of b, or 0 if b does not start with one. Such characters, as
+// used in mDNS names, are kept in names, while other bytes above 0x7e are
+// escaped.
+func printableRune(b []byte) int {
+	if len(b) == 0 || b[0] < utf8.RuneSelf {
+		return 0
+	}
+	r, n := utf8.DecodeRune(b)
+	if r == utf8.RuneError || !unicode.IsPrint(r) {
+		return 0
+	}
+	return n
+}
+
+// rawLabel returns the bytes of a label on the wire, resolving its escapes.
+// Invalid escapes are kept as they are.
+func rawLabel(label string) string {
+	raw, err := unescapeText(label)
+	if err != nil {
+		return label
+	}
+	return raw
+}
+
+// CanonicalName returns name in the canonical form of RFC 4034 section 6.2,
+// with all ASCII letters in lower case. Names in other cases are equal in DNS
+// (RFC 4343). Escapes in labels are rewritten to the form used by ParseName.
+func CanonicalName(name string) string {
+	if isCanonical(name) {
+		return name
+	}
+	if strings.IndexByte(name, '\\') >= 0 {
+		labels := nameLabels(name)
+		for i, label := range labels {
+			labels[i] = escapeLabel([]byte(rawLabel(label)))
+		}
+		name = strings.Join(labels, ".")
+	}
+	b := []byte(name)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// isCanonical reports whether name has no upper case letters or escapes
+func isCanonical(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; (c >= 'A' && c <= 'Z') || c == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// nameLabels splits name into labels at the dots that are not escaped, the
+// root domain having no labels. The labels keep their escapes.
+func nameLabels(name string) []string {
+	if name == "" {
+		return nil
+	}
+	return splitEscaped(name, '.')
+}
+
+// parentName returns name without its first label
+func parentName(name string) string {
+	labels := nameLabels(name)
+	if len(labels) < 2 {
+		return ""
+	}
+	return name[len(labels[0])+1:]
+}
+
+// CompareNames compares names in the canonical DNS name order of RFC 4034
+// section 6.1, returning -1, 0 or 1
+func CompareNames(a, b string) int {
+	la := nameLabels(CanonicalName(a))
+	lb := nameLabels(CanonicalName(b))
+	for i, j := len(la)-1, len(lb)-1; i >= 0 && j >= 0; i, j = i-1, j-1 {
+		if c := strings.Compare(rawLabel(la[i]), rawLabel(lb[j])); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(la) < len(lb):
+		return -1
+	case len(la) > len(lb):
+		return 1
+	}
+	return 0
+}
+
+// EqualNames reports whether a and b are the same name, ignoring ASCII case
+func EqualNames(a, b string) bool {
+	return CanonicalName(a) == CanonicalName(b)
+}
+
+// IsSubdomain reports whether child is equal to or below parent
+func IsSubdomain(child, parent string) bool {
+	lc, lp := nameLabels(CanonicalName(child)), nameLabels(CanonicalName(parent))
+	if len(lp) > len(lc) {
+		return false
+	}
+	for i, label := range lp {
+		if lc[len(lc)-len(lp)+i] != label {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildName returns a dns encoded name with pointers if possible. Passing nil
+// domains builds the name without compression. The lengths of labels and of
+// the name are not checked, the Build methods of records and questions reject
+// names that do not fit on the wire.
 func BuildName(name string, domains *Domains) string {
 	// root domain
 	if len(name) == 0 {
@@ -73,19 +279,34 @@ func BuildName(name string, domains *Domains) string {
 	}
 
 	var buf bytes.Buffer
-	written := 0
-	name = name + "."
-	for i, c := range name {
-		if c == '.' {
-			if n, ok := domains.GetBuild(name[written : len(name)-1]); ok {
-				binary.Write(&buf, binary.BigEndian, uint16(NamePointer<<8|n&0xff))
-				return buf.String()
-			}
-			buf.WriteByte(uint8(i - written))
-			buf.WriteString(name[written:i])
-			written = written + (i - written) + 1
+	suffix := name
+	for _, label := range nameLabels(name) {
+		if n, ok := domains.GetBuild(suffix); ok {
+			binary.Write(&buf, binary.BigEndian, uint16(NamePointer<<8|n))
+			return buf.String()
 		}
+		raw := rawLabel(label)
+		buf.WriteByte(uint8(len(raw)))
+		buf.WriteString(raw)
+		suffix = suffix[min(len(label)+1, len(suffix)):]
 	}
 	buf.WriteByte('\x00')
 	return buf.String()
 }
+
+// buildName returns BuildName of name, or ErrLabelTooLong or ErrNameTooLong
+// if the escapes of name make a label or the name too long for the wire
+func buildName(name string, domains *Domains) (string, error) {
+	length := 1
+	for _, label := range nameLabels(name) {
+		raw := rawLabel(label)
+		if len(raw) > maxLabelLength {
+			return "", fmt.Errorf("%w: length %d", ErrLabelTooLong, len(raw))
+		}
+		length += 1 + len(raw)
+	}
+	if length > maxNameLength {
+		return "", fmt.Errorf("%w: length %d", ErrNameTooLong, length)
+	}
+	return BuildName(name, domains), nil
+}
@@ -2,6 +2,10 @@ package dns
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -93,6 +97,36 @@ func TestMessage_ParseName(t *testing.T) {
 			want:    "",
 			wantErr: true,
 		},
+		{
+			name: "escaped labels",
+			args: args{
+				bytes:   []byte("\x0fMy Printer v2.1\x03a\\b\x01\x00\x05local\x00"),
+				pointer: 0,
+				domains: NewDomains(),
+			},
+			want:        `My Printer v2\.1.a\\b.\000.local`,
+			wantDomains: map[int]string{0: `My Printer v2\.1.a\\b.\000.local`, 16: `a\\b.\000.local`, 20: `\000.local`, 22: "local"},
+		},
+		{
+			name: "UTF-8 mDNS label",
+			args: args{
+				bytes:   []byte("\x12Living Room\xe2\x80\x99s TV\x05local\x00"),
+				pointer: 0,
+				domains: NewDomains(),
+			},
+			want:        "Living Room’s TV.local",
+			wantDomains: map[int]string{0: "Living Room’s TV.local", 19: "local"},
+		},
+		{
+			name: "invalid UTF-8 label",
+			args: args{
+				bytes:   []byte("\x03a\xe2\x80\x05local\x00"),
+				pointer: 0,
+				domains: NewDomains(),
+			},
+			want:        `a\226\128.local`,
+			wantDomains: map[int]string{0: `a\226\128.local`, 4: "local"},
+		},
 		{
 			name: "root domain",
 			args: args{
@@ -165,6 +199,22 @@ func TestBuildName(t *testing.T) {
 			},
 			want: "\x03sub\xc0\x2a",
 		},
+		{
+			name: "cached domain name in other case",
+			args: args{
+				name:    "Sub.DOMAIN.test",
+				domains: &Domains{buildPtr: map[string]int{"domain.test": 42}},
+			},
+			want: "\x03Sub\xc0\x2a",
+		},
+		{
+			name: "pointer beyond first byte",
+			args: args{
+				name:    "sub.domain.test",
+				domains: &Domains{buildPtr: map[string]int{"domain.test": 0x1234}},
+			},
+			want: "\x03sub\xd2\x34",
+		},
 		{
 			name: "root domain",
 			args: args{
@@ -173,6 +223,30 @@ func TestBuildName(t *testing.T) {
 			},
 			want: "\x00",
 		},
+		{
+			name: "escaped labels",
+			args: args{
+				name:    `My Printer v2\.1.a\\b.\000.local`,
+				domains: NewDomains(),
+			},
+			want: "\x0fMy Printer v2.1\x03a\\b\x01\x00\x05local\x00",
+		},
+		{
+			name: "escaped label with cached suffix",
+			args: args{
+				name:    `v2\.1.local`,
+				domains: &Domains{buildPtr: map[string]int{"local": 42}},
+			},
+			want: "\x04v2.1\xc0\x2a",
+		},
+		{
+			name: "uncompressed domain name",
+			args: args{
+				name:    "sub.domain.test",
+				domains: nil,
+			},
+			want: "\x03sub\x06domain\x04test\x00",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -190,3 +264,232 @@ func BenchmarkNameParsing(b *testing.B) {
 		ParseName(bytes.NewBuffer(buf), 0, NewDomains())
 	}
 }
+
+func TestBuild_NameTooLong(t *testing.T) {
+	// Escapes are short in the string form, but not on the wire
+	longLabel := strings.Repeat(`\.`, 64) + ".example"
+	label := strings.Repeat(`\065`, 63)
+	longName := strings.Join([]string{label, label, label, label}, ".")
+	tests := []struct {
+		name    string
+		build   func(*bytes.Buffer) error
+		wantErr error
+	}{
+		{
+			name: "Question label",
+			build: func(buf *bytes.Buffer) error {
+				q := &Question{Domain: longLabel, Type: A, Class: IN}
+				return q.Build(buf, NewDomains())
+			},
+			wantErr: ErrLabelTooLong,
+		},
+		{
+			name: "Owner name",
+			build: func(buf *bytes.Buffer) error {
+				r := &Record{Name: longName, Type: A, Class: uint16(IN), Data: &IPv4{}}
+				return r.Build(buf, NewDomains())
+			},
+			wantErr: ErrNameTooLong,
+		},
+		{
+			name: "CNAME target",
+			build: func(buf *bytes.Buffer) error {
+				r := &Record{Name: "example", Type: CNAME, Class: uint16(IN),
+					Data: &CName{Name: longLabel}}
+				return r.Build(buf, NewDomains())
+			},
+			wantErr: ErrLabelTooLong,
+		},
+		{
+			name: "SOA RNAME",
+			build: func(buf *bytes.Buffer) error {
+				r := &Record{Name: "example", Type: SOA, Class: uint16(IN),
+					Data: &Soa{MName: "ns.example", RName: longName}}
+				return r.Build(buf, NewDomains())
+			},
+			wantErr: ErrNameTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.build(new(bytes.Buffer)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseName_Malformed(t *testing.T) {
+	long := bytes.Repeat([]byte("\x3f"+string(bytes.Repeat([]byte("a"), 63))), 4)
+	tests := []struct {
+		name    string
+		bytes   []byte
+		pointer int
+		domains *Domains
+		wantErr error
+	}{
+		{
+			name:    "Pointer to itself",
+			bytes:   []byte("\xc0\x00"),
+			domains: NewDomains(),
+			wantErr: ErrPointerLoop,
+		},
+		{
+			name:    "Pointer into own labels",
+			bytes:   []byte("\x03sub\xc0\x00"),
+			domains: NewDomains(),
+			wantErr: ErrPointerLoop,
+		},
+		{
+			name:    "Forward pointer",
+			bytes:   []byte("\x03sub\xc0\x08\x00\x04test\x00"),
+			domains: &Domains{parsePtr: map[int]string{8: "test"}},
+			wantErr: ErrPointerLoop,
+		},
+		{
+			name:    "Label too long",
+			bytes:   append([]byte("\x40"), bytes.Repeat([]byte("a"), 64)...),
+			domains: NewDomains(),
+			wantErr: ErrLabelTooLong,
+		},
+		{
+			name:    "Name too long",
+			bytes:   append(long, 0),
+			domains: NewDomains(),
+			wantErr: ErrNameTooLong,
+		},
+		{
+			name:    "Name too long through pointer",
+			bytes:   []byte("\x00\x3fxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx\xc0\x00"),
+			pointer: 1,
+			domains: &Domains{parsePtr: map[int]string{0: string(bytes.Repeat([]byte("b"), 200))}},
+			wantErr: ErrNameTooLong,
+		},
+		{
+			name:    "Truncated label",
+			bytes:   []byte("\x06dom"),
+			domains: NewDomains(),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseName(bytes.NewBuffer(tt.bytes[tt.pointer:]), tt.pointer, tt.domains)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseName() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompareNames(t *testing.T) {
+	// Canonical order example from RFC 4034 section 6.1
+	want := []string{
+		"example",
+		"a.example",
+		"yljkjljk.a.example",
+		"Z.a.example",
+		"zABC.a.EXAMPLE",
+		"z.example",
+		"\x01.z.example",
+		"*.z.example",
+		"\x80.z.example",
+	}
+	got := []string{
+		want[5], want[8], want[0], want[3], want[6], want[1], want[7], want[4], want[2],
+	}
+	sort.Slice(got, func(i, j int) bool { return CompareNames(got[i], got[j]) < 0 })
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("CompareNames() order = %q, want %q", got, want)
+			break
+		}
+	}
+	if CompareNames("Example.COM", "example.com") != 0 {
+		t.Errorf("CompareNames() is case sensitive")
+	}
+}
+
+func TestEqualNames(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"example.com", "example.com", true},
+		{"Example.COM", "eXample.com", true},
+		{"", "", true},
+		{"example.com", "example.org", false},
+		{`a\.b.com`, `A\046B.com`, true},
+		{`a\.b.com`, "a.b.com", false},
+		{"www.example.com", "example.com", false},
+		{"\xc9xample.com", "\xe9xample.com", false},
+	}
+	for _, tt := range tests {
+		if got := EqualNames(tt.a, tt.b); got != tt.want {
+			t.Errorf("EqualNames(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIsSubdomain(t *testing.T) {
+	tests := []struct {
+		child, parent string
+		want          bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.Example.com", "example.COM", true},
+		{"a.b.example.com", "example.com", true},
+		{"example.com", "", true},
+		{"notexample.com", "example.com", false},
+		{`www\.example.com`, "example.com", false},
+		{`www.a\.b.com`, `A\046b.com`, true},
+		{"example.com", "www.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := IsSubdomain(tt.child, tt.parent); got != tt.want {
+			t.Errorf("IsSubdomain(%q, %q) = %v, want %v", tt.child, tt.parent, got, tt.want)
+		}
+	}
+}
+
+func TestParseMessage_MalformedRDATAName(t *testing.T) {
+	// answer returns a response with a single answer of type rtype holding rdata
+	// at offset 23
+	answer := func(rtype Type, rdata []byte) []byte {
+		msg := []byte("\x00\x00\x80\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00")
+		msg = append(msg, byte(rtype>>8), byte(rtype), 0, 1, 0, 0, 0, 60,
+			byte(len(rdata)>>8), byte(len(rdata)))
+		return append(msg, rdata...)
+	}
+	tests := []struct {
+		name    string
+		msg     []byte
+		wantErr error
+	}{
+		{
+			name:    "CNAME pointing to itself",
+			msg:     answer(CNAME, []byte("\xc0\x17")),
+			wantErr: ErrPointerLoop,
+		},
+		{
+			name:    "SOA RNAME pointing forward",
+			msg:     answer(SOA, []byte("\x00\xc0\x19\x00")),
+			wantErr: ErrPointerLoop,
+		},
+		{
+			name:    "MX exchange label too long",
+			msg:     answer(MX, append([]byte("\x00\x0a\x40"), bytes.Repeat([]byte("a"), 65)...)),
+			wantErr: ErrLabelTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMessage(bytes.NewBuffer(tt.msg)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseMessage() error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := Unpack(tt.msg); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Unpack() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
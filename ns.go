@@ -0,0 +1,53 @@
+package dns
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Ns implements interface RData
+type Ns struct {
+	Name  string
+	bytes string
+}
+
+// Parse implements NS parsing for interface RData
+func (n *Ns) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
+	name, err := ParseName(buf, ptr, domains)
+	if err != nil {
+		return fmt.Errorf("unable to parse NS: %w", err)
+	}
+	n.Name = name
+	return nil
+}
+
+// Build implements NS building for interface RData
+func (n *Ns) Build(buf *bytes.Buffer, domains *Domains) error {
+	domains.SetBuild(buf.Len(), n.Name)
+	buf.WriteString(n.bytes)
+	return nil
+}
+
+// PreBuild implements NS pre building for interface RData
+func (n *Ns) PreBuild(_ *Record, domains *Domains) (int, error) {
+	var err error
+	if n.bytes, err = buildName(n.Name, domains); err != nil {
+		return 0, err
+	}
+	return len(n.bytes), nil
+}
+
+// TransformName satisfies the interface
+func (*Ns) TransformName(name string) string { return name }
+
+// parseText implements presentation format parsing of NS records
+func (n *Ns) parseText(t *rdataText) error {
+	name, err := t.name("name")
+	n.Name = name
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (n *Ns) String() string { return n.format(FormatOptions{}) }
+
+func (n *Ns) format(opts FormatOptions) string { return opts.name(n.Name) }
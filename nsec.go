@@ -0,0 +1,151 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Nsec implements interface RData
+type Nsec struct {
+	NextDomain string
+	TypeBitMap []Type
+	nextBytes  string
+	length     uint16
+}
+
+// Parse implements NSEC parsing for interface RData
+func (n *Nsec) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
+	bufLen := buf.Len()
+	if bufLen < int(n.length) {
+		return fmt.Errorf("unable to read NSEC: length %d, %d bytes left",
+			n.length, bufLen)
+	}
+	name, err := ParseName(buf, ptr, domains)
+	if err != nil {
+		return fmt.Errorf("unable to parse NSEC next domain: %w", err)
+	}
+	n.NextDomain = name
+	bitMapLen := int(n.length) - (bufLen - buf.Len())
+	if bitMapLen < 0 {
+		return fmt.Errorf("NSEC next domain exceeds RDATA length %d", n.length)
+	}
+	n.TypeBitMap, err = parseTypeBitMap(buf.Next(bitMapLen))
+	return err
+}
+
+// Build implements NSEC building for interface RData
+func (n *Nsec) Build(buf *bytes.Buffer, _ *Domains) error {
+	buf.WriteString(n.nextBytes)
+	buf.Write(buildTypeBitMap(n.TypeBitMap))
+	return nil
+}
+
+// PreBuild builds the next domain name, which must not be compressed as
+// required by RFC 4034
+func (n *Nsec) PreBuild(_ *Record, _ *Domains) (int, error) {
+	var err error
+	if n.nextBytes, err = buildName(n.NextDomain, nil); err != nil {
+		return 0, err
+	}
+	return len(n.nextBytes) + len(buildTypeBitMap(n.TypeBitMap)), nil
+}
+
+// TransformName satisfies the interface
+func (*Nsec) TransformName(name string) string { return name }
+
+// HasType reports whether t is set in the type bit map
+func (n *Nsec) HasType(t Type) bool {
+	return hasType(n.TypeBitMap, t)
+}
+
+func hasType(types []Type, t Type) bool {
+	for _, tt := range types {
+		if tt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTypeBitMap decodes the windowed type bit map of RFC 4034 section 4.1.2
+func parseTypeBitMap(b []byte) ([]Type, error) {
+	var types []Type
+	lastWindow := -1
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errors.New("type bit map window truncated")
+		}
+		window, length := int(b[0]), int(b[1])
+		if window <= lastWindow {
+			return nil, errors.New("type bit map windows not in ascending order")
+		}
+		if length == 0 || length > 32 || len(b) < length+2 {
+			return nil, fmt.Errorf("invalid type bit map window length: %d", length)
+		}
+		for i, octet := range b[2 : length+2] {
+			for bit := 0; bit < 8; bit++ {
+				if octet&(0x80>>bit) != 0 {
+					types = append(types, Type(window<<8|i*8+bit))
+				}
+			}
+		}
+		lastWindow = window
+		b = b[length+2:]
+	}
+	return types, nil
+}
+
+// buildTypeBitMap encodes types into the windowed type bit map of RFC 4034
+// section 4.1.2
+func buildTypeBitMap(types []Type) []byte {
+	if len(types) == 0 {
+		return nil
+	}
+	sorted := make([]Type, len(types))
+	copy(sorted, types)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var out []byte
+	var bitmap [32]byte
+	window, length := int(sorted[0]>>8), 0
+	flush := func() {
+		out = append(out, byte(window), byte(length))
+		out = append(out, bitmap[:length]...)
+		bitmap = [32]byte{}
+	}
+	for _, t := range sorted {
+		if int(t>>8) != window {
+			flush()
+			window, length = int(t>>8), 0
+		}
+		octet := int(t&0xff) / 8
+		bitmap[octet] |= 0x80 >> (t & 0x7)
+		if octet+1 > length {
+			length = octet + 1
+		}
+	}
+	flush()
+	return out
+}
+
+// parseText implements presentation format parsing of NSEC records
+func (n *Nsec) parseText(t *rdataText) error {
+	var err error
+	if n.NextDomain, err = t.name("next domain"); err != nil {
+		return err
+	}
+	n.TypeBitMap, err = t.types()
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (n *Nsec) String() string { return n.format(FormatOptions{}) }
+
+func (n *Nsec) format(opts FormatOptions) string {
+	if len(n.TypeBitMap) == 0 {
+		return opts.name(n.NextDomain)
+	}
+	return opts.name(n.NextDomain) + " " + typesString(n.TypeBitMap)
+}
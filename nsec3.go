@@ -0,0 +1,220 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Nsec3 implements interface RData
+type Nsec3 struct {
+	HashAlgorithm uint8
+	Flags         uint8
+	Iterations    uint16
+	Salt          []byte
+	NextHashed    []byte
+	TypeBitMap    []Type
+	length        uint16
+}
+
+// Parse implements NSEC3 parsing for interface RData
+func (n *Nsec3) Parse(buf *bytes.Buffer, _ int, _ *Domains) error {
+	if buf.Len() < int(n.length) {
+		return fmt.Errorf("unable to read NSEC3: length %d, %d bytes left",
+			n.length, buf.Len())
+	}
+	rdata := buf.Next(int(n.length))
+	read, err := parseNsec3Params(rdata, &n.HashAlgorithm, &n.Flags,
+		&n.Iterations, &n.Salt)
+	if err != nil {
+		return err
+	}
+	rdata = rdata[read:]
+	if len(rdata) < 1 || len(rdata) < int(rdata[0])+1 {
+		return errors.New("NSEC3 next hashed owner name truncated")
+	}
+	n.NextHashed = append([]byte(nil), rdata[1:int(rdata[0])+1]...)
+	n.TypeBitMap, err = parseTypeBitMap(rdata[int(rdata[0])+1:])
+	return err
+}
+
+// Build implements NSEC3 building for interface RData
+func (n *Nsec3) Build(buf *bytes.Buffer, _ *Domains) error {
+	buildNsec3Params(buf, n.HashAlgorithm, n.Flags, n.Iterations, n.Salt)
+	buf.WriteByte(uint8(len(n.NextHashed)))
+	buf.Write(n.NextHashed)
+	buf.Write(buildTypeBitMap(n.TypeBitMap))
+	return nil
+}
+
+// PreBuild step, checking the field lengths and returning record size
+func (n *Nsec3) PreBuild(_ *Record, _ *Domains) (int, error) {
+	if len(n.Salt) > 255 {
+		return 0, fmt.Errorf("NSEC3 salt too long: %d", len(n.Salt))
+	}
+	if len(n.NextHashed) > 255 {
+		return 0, fmt.Errorf("NSEC3 next hashed owner name too long: %d", len(n.NextHashed))
+	}
+	return 6 + len(n.Salt) + len(n.NextHashed) +
+		len(buildTypeBitMap(n.TypeBitMap)), nil
+}
+
+// TransformName satisfies the interface
+func (*Nsec3) TransformName(name string) string { return name }
+
+// OptOut reports whether the Opt-Out flag is set
+func (n *Nsec3) OptOut() bool {
+	return n.Flags&Nsec3OptOut == Nsec3OptOut
+}
+
+// HasType reports whether t is set in the type bit map
+func (n *Nsec3) HasType(t Type) bool {
+	return hasType(n.TypeBitMap, t)
+}
+
+// Nsec3Param implements interface RData
+type Nsec3Param struct {
+	HashAlgorithm uint8
+	Flags         uint8
+	Iterations    uint16
+	Salt          []byte
+	length        uint16
+}
+
+// Parse implements NSEC3PARAM parsing for interface RData
+func (n *Nsec3Param) Parse(buf *bytes.Buffer, _ int, _ *Domains) error {
+	if buf.Len() < int(n.length) {
+		return fmt.Errorf("unable to read NSEC3PARAM: length %d, %d bytes left",
+			n.length, buf.Len())
+	}
+	rdata := buf.Next(int(n.length))
+	read, err := parseNsec3Params(rdata, &n.HashAlgorithm, &n.Flags,
+		&n.Iterations, &n.Salt)
+	if err != nil {
+		return err
+	}
+	if read != len(rdata) {
+		return fmt.Errorf("NSEC3PARAM has %d trailing bytes", len(rdata)-read)
+	}
+	return nil
+}
+
+// Build implements NSEC3PARAM building for interface RData
+func (n *Nsec3Param) Build(buf *bytes.Buffer, _ *Domains) error {
+	buildNsec3Params(buf, n.HashAlgorithm, n.Flags, n.Iterations, n.Salt)
+	return nil
+}
+
+// PreBuild step, checking the salt length and returning record size
+func (n *Nsec3Param) PreBuild(_ *Record, _ *Domains) (int, error) {
+	if len(n.Salt) > 255 {
+		return 0, fmt.Errorf("NSEC3PARAM salt too long: %d", len(n.Salt))
+	}
+	return 5 + len(n.Salt), nil
+}
+
+// TransformName satisfies the interface
+func (*Nsec3Param) TransformName(name string) string { return name }
+
+// parseNsec3Params reads the fields shared by NSEC3 and NSEC3PARAM, returning
+// the number of bytes read
+func parseNsec3Params(rdata []byte, alg, flags *uint8, iterations *uint16,
+	salt *[]byte,
+) (int, error) {
+	if len(rdata) < 5 || len(rdata) < 5+int(rdata[4]) {
+		return 0, errors.New("NSEC3 parameters truncated")
+	}
+	*alg = rdata[0]
+	*flags = rdata[1]
+	*iterations = binary.BigEndian.Uint16(rdata[2:])
+	*salt = append([]byte{}, rdata[5:5+int(rdata[4])]...)
+	return 5 + int(rdata[4]), nil
+}
+
+// buildNsec3Params writes the fields shared by NSEC3 and NSEC3PARAM. The salt
+// length is checked by PreBuild.
+func buildNsec3Params(buf *bytes.Buffer, alg, flags uint8, iterations uint16, salt []byte) {
+	buf.WriteByte(alg)
+	buf.WriteByte(flags)
+	binary.Write(buf, binary.BigEndian, iterations)
+	buf.WriteByte(uint8(len(salt)))
+	buf.Write(salt)
+}
+
+// parseText implements presentation format parsing of NSEC3 records
+func (n *Nsec3) parseText(t *rdataText) error {
+	err := parseNsec3TextParams(t, &n.HashAlgorithm, &n.Flags, &n.Iterations, &n.Salt)
+	if err != nil {
+		return err
+	}
+	next, err := t.next("next hashed owner")
+	if err != nil {
+		return err
+	}
+	if n.NextHashed, err = nsec3Encoding.DecodeString(strings.ToUpper(next)); err != nil {
+		return fmt.Errorf("invalid next hashed owner %q", next)
+	}
+	n.TypeBitMap, err = t.types()
+	return err
+}
+
+// parseText implements presentation format parsing of NSEC3PARAM records
+func (n *Nsec3Param) parseText(t *rdataText) error {
+	return parseNsec3TextParams(t, &n.HashAlgorithm, &n.Flags, &n.Iterations, &n.Salt)
+}
+
+// parseNsec3TextParams parses the presentation format fields shared by NSEC3
+// and NSEC3PARAM, where a salt of "-" is empty
+func parseNsec3TextParams(t *rdataText, alg, flags *uint8, iterations *uint16,
+	salt *[]byte,
+) error {
+	var err error
+	if *alg, err = t.uint8("hash algorithm"); err != nil {
+		return err
+	}
+	if *flags, err = t.uint8("flags"); err != nil {
+		return err
+	}
+	if *iterations, err = t.uint16("iterations"); err != nil {
+		return err
+	}
+	s, err := t.next("salt")
+	if err != nil {
+		return err
+	}
+	if s == "-" {
+		*salt = nil
+		return nil
+	}
+	if *salt, err = hex.DecodeString(s); err != nil {
+		return fmt.Errorf("invalid salt %q", s)
+	}
+	return nil
+}
+
+// String returns the RDATA in presentation format
+func (n *Nsec3) String() string {
+	s := fmt.Sprintf("%d %d %d %s %s", n.HashAlgorithm, n.Flags, n.Iterations,
+		saltString(n.Salt), strings.ToLower(nsec3Encoding.EncodeToString(n.NextHashed)))
+	if len(n.TypeBitMap) > 0 {
+		s += " " + typesString(n.TypeBitMap)
+	}
+	return s
+}
+
+// String returns the RDATA in presentation format
+func (n *Nsec3Param) String() string {
+	return fmt.Sprintf("%d %d %d %s", n.HashAlgorithm, n.Flags, n.Iterations, saltString(n.Salt))
+}
+
+// saltString returns the presentation format of an NSEC3 salt, where an empty
+// salt is "-"
+func saltString(salt []byte) string {
+	if len(salt) == 0 {
+		return "-"
+	}
+	return hex.EncodeToString(salt)
+}
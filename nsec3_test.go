@@ -0,0 +1,174 @@
+package dns
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var (
+	nsec3Wire = []byte("\x01\x01\x00\x0c\x04\xaa\xbb\xcc\xdd" +
+		"\x14\x2c\x0b\xd3\xd4\x92\x9a\x62\x3b\x08\xa1\xa4\xcc\xe3\xc4\x59\xc6\x50\x8d\x8b\x61" +
+		"\x00\x07\x22\x01\x00\x00\x00\x02\x90")
+	nsec3Data = &Nsec3{
+		HashAlgorithm: Nsec3SHA1,
+		Flags:         Nsec3OptOut,
+		Iterations:    12,
+		Salt:          []byte("\xaa\xbb\xcc\xdd"),
+		NextHashed:    mustDecodeHex("2c0bd3d4929a623b08a1a4cce3c459c6508d8b61"),
+		TypeBitMap:    []Type{NS, SOA, MX, RRSIG, DNSKEY, NSEC3PARAM},
+	}
+	nsec3ParamWire = []byte("\x01\x00\x00\x0c\x04\xaa\xbb\xcc\xdd")
+)
+
+func TestNsec3_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    *Nsec3
+		wantErr bool
+	}{
+		{
+			name: "Simple NSEC3",
+			buf:  nsec3Wire,
+			want: nsec3Data,
+		},
+		{
+			name:    "Truncated salt",
+			buf:     nsec3Wire[:7],
+			wantErr: true,
+		},
+		{
+			name:    "Truncated hash",
+			buf:     nsec3Wire[:15],
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &Nsec3{length: uint16(len(tt.buf))}
+			if err := n.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Nsec3.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			n.length = 0
+			if !reflect.DeepEqual(n, tt.want) {
+				t.Errorf("Nsec3.Parse() = %+v, want %+v", n, tt.want)
+			}
+			if !n.OptOut() {
+				t.Errorf("Nsec3.OptOut() = false, want true")
+			}
+		})
+	}
+}
+
+func TestNsec3_Build(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if length, err := nsec3Data.PreBuild(&Record{}, NewDomains()); err != nil || length != len(nsec3Wire) {
+		t.Errorf("Nsec3.PreBuild() = %v, %v, want %v", length, err, len(nsec3Wire))
+		return
+	}
+	if err := nsec3Data.Build(buf, NewDomains()); err != nil {
+		t.Errorf("Nsec3.Build() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(buf.Bytes(), nsec3Wire) {
+		t.Errorf("Nsec3.Build() = %v, want %v", buf.Bytes(), nsec3Wire)
+	}
+}
+
+func TestRecord_Build_Nsec3TooLong(t *testing.T) {
+	long := make([]byte, 256)
+	tests := []struct {
+		name string
+		r    Record
+	}{
+		{
+			name: "NSEC3 salt",
+			r:    Record{Type: NSEC3, Class: uint16(IN), Data: &Nsec3{Salt: long}},
+		},
+		{
+			name: "NSEC3 next hashed owner name",
+			r:    Record{Type: NSEC3, Class: uint16(IN), Data: &Nsec3{NextHashed: long}},
+		},
+		{
+			name: "NSEC3PARAM salt",
+			r:    Record{Type: NSEC3PARAM, Class: uint16(IN), Data: &Nsec3Param{Salt: long}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if err := tt.r.Build(buf, nil); err == nil {
+				t.Fatal("Record.Build() error = nil, want error")
+			}
+			// Only the owner name is written before the RDATA is checked
+			if buf.Len() != 1 {
+				t.Errorf("Record.Build() wrote %d bytes, want only the owner name", buf.Len())
+			}
+		})
+	}
+}
+
+func TestNsec3Param_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    *Nsec3Param
+		wantErr bool
+	}{
+		{
+			name: "Simple NSEC3PARAM",
+			buf:  nsec3ParamWire,
+			want: &Nsec3Param{
+				HashAlgorithm: Nsec3SHA1,
+				Iterations:    12,
+				Salt:          []byte("\xaa\xbb\xcc\xdd"),
+			},
+		},
+		{
+			name:    "Trailing data",
+			buf:     append([]byte("\x01\x00\x00\x0c\x00"), 0xff),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &Nsec3Param{length: uint16(len(tt.buf))}
+			if err := n.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Nsec3Param.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			n.length = 0
+			if !reflect.DeepEqual(n, tt.want) {
+				t.Errorf("Nsec3Param.Parse() = %+v, want %+v", n, tt.want)
+			}
+		})
+	}
+}
+
+func TestNsec3Param_Build(t *testing.T) {
+	n := &Nsec3Param{
+		HashAlgorithm: Nsec3SHA1,
+		Iterations:    12,
+		Salt:          []byte("\xaa\xbb\xcc\xdd"),
+	}
+	buf := new(bytes.Buffer)
+	if length, err := n.PreBuild(&Record{}, NewDomains()); err != nil || length != len(nsec3ParamWire) {
+		t.Errorf("Nsec3Param.PreBuild() = %v, %v, want %v", length, err, len(nsec3ParamWire))
+		return
+	}
+	if err := n.Build(buf, NewDomains()); err != nil {
+		t.Errorf("Nsec3Param.Build() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(buf.Bytes(), nsec3ParamWire) {
+		t.Errorf("Nsec3Param.Build() = %v, want %v", buf.Bytes(), nsec3ParamWire)
+	}
+}
@@ -0,0 +1,81 @@
+package dns
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// Example NSEC from RFC 4034 section 4.3
+var nsecWire = []byte("\x04host\x07example\x03com\x00" +
+	"\x00\x06\x40\x01\x00\x00\x00\x03" +
+	"\x04\x1b\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+	"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x20")
+
+func TestNsec_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    *Nsec
+		wantErr bool
+	}{
+		{
+			name: "RFC 4034 example",
+			buf:  nsecWire,
+			want: &Nsec{
+				NextDomain: "host.example.com",
+				TypeBitMap: []Type{A, MX, RRSIG, NSEC, 1234},
+			},
+		},
+		{
+			name:    "Windows out of order",
+			buf:     []byte("\x00\x01\x00\x01\x40\x00\x01\x40"),
+			wantErr: true,
+		},
+		{
+			name:    "Window too long",
+			buf:     []byte("\x00\x00\x21\x40"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &Nsec{length: uint16(len(tt.buf))}
+			if err := n.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Nsec.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			n.length = 0
+			if !reflect.DeepEqual(n, tt.want) {
+				t.Errorf("Nsec.Parse() = %+v, want %+v", n, tt.want)
+			}
+		})
+	}
+}
+
+func TestNsec_Build(t *testing.T) {
+	domains := NewDomains()
+	domains.SetBuild(12, "example.com")
+	n := &Nsec{
+		NextDomain: "host.example.com",
+		TypeBitMap: []Type{NSEC, 1234, A, RRSIG, MX},
+	}
+	buf := new(bytes.Buffer)
+	if length, err := n.PreBuild(&Record{}, domains); err != nil || length != len(nsecWire) {
+		t.Errorf("Nsec.PreBuild() = %v, %v, want %v", length, err, len(nsecWire))
+		return
+	}
+	if err := n.Build(buf, domains); err != nil {
+		t.Errorf("Nsec.Build() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(buf.Bytes(), nsecWire) {
+		t.Errorf("Nsec.Build() = %v, want %v", buf.Bytes(), nsecWire)
+	}
+	if !n.HasType(MX) || n.HasType(AAAA) {
+		t.Errorf("Nsec.HasType() wrong for %v", n.TypeBitMap)
+	}
+}
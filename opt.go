@@ -6,14 +6,42 @@ import (
 	"fmt"
 )
 
+// EDNS option codes from the IANA registry
+const (
+	OptionCodeNSID          = 3
+	OptionCodeClientSubnet  = 8
+	OptionCodeExpire        = 9
+	OptionCodeCookie        = 10
+	OptionCodeTCPKeepalive  = 11
+	OptionCodePadding       = 12
+	OptionCodeChain         = 13
+	OptionCodeExtendedError = 15
+)
+
+// DefaultEDNSSize is the UDP payload size of OPT records added by SetOption,
+// which avoids IP fragmentation on most paths
+const DefaultEDNSSize = 1232
+
+// EDNSOption is a single option in the RDATA of an OPT record (RFC 6891)
+type EDNSOption interface {
+	Code() uint16
+	Parse([]byte) error
+	Build(*bytes.Buffer) error
+}
+
 // Opt implements interface for RDATA
 type Opt struct {
 	UDPSize     uint16
 	RCode       byte
 	EDNSVersion byte
 	DNSSec      bool
+	// Record is the record the OPT is parsed from, whose class and TTL hold
+	// the EDNS fields. It is only used while parsing and is not kept up to
+	// date when the record is copied, e.g. into or within a message section,
+	// so use the fields of Opt instead.
 	Record      *Record
-	Options     map[uint16][]byte
+	Options     []EDNSOption
+	optionBytes []byte
 }
 
 // Parse implements OPT parsing for interface RData
@@ -25,19 +53,28 @@ func (o *Opt) Parse(buf *bytes.Buffer, _ int, _ *Domains) error {
 		o.DNSSec = true
 	}
 
-	readLen := o.Record.Length
-	o.Options = map[uint16][]byte{}
-	for readLen > 0 {
+	if int(o.Record.Length) > buf.Len() {
+		return fmt.Errorf("OPT length out of range: %d > %d", o.Record.Length, buf.Len())
+	}
+	options := bytes.NewBuffer(buf.Next(int(o.Record.Length)))
+	o.Options = nil
+	for options.Len() > 0 {
 		var code uint16
 		var length uint16
-		if err := binary.Read(buf, binary.BigEndian, &code); err != nil {
+		if err := binary.Read(options, binary.BigEndian, &code); err != nil {
 			return fmt.Errorf("unable to read variable OPT code: %w", err)
 		}
-		if err := binary.Read(buf, binary.BigEndian, &length); err != nil {
+		if err := binary.Read(options, binary.BigEndian, &length); err != nil {
 			return fmt.Errorf("unable to read variable OPT length: %w", err)
 		}
-		o.Options[code] = buf.Next(int(length))
-		readLen = readLen - length - 4
+		if int(length) > options.Len() {
+			return fmt.Errorf("OPT option %d too long: %d > %d", code, length, options.Len())
+		}
+		option := newEDNSOption(code)
+		if err := option.Parse(options.Next(int(length))); err != nil {
+			return fmt.Errorf("unable to parse OPT option %d: %w", code, err)
+		}
+		o.Options = append(o.Options, option)
 	}
 	return nil
 }
@@ -51,15 +88,83 @@ func (o *Opt) PreBuild(r *Record, _ *Domains) (int, error) {
 	r.Class = o.UDPSize
 	r.TTL = (uint32(o.RCode) << 24) |
 		((uint32(o.EDNSVersion) & 0xff) << 16) | (DNSSec << 15)
-	return 0, nil
+
+	var err error
+	if o.optionBytes, err = o.buildOptions(); err != nil {
+		return 0, err
+	}
+	return len(o.optionBytes), nil
 }
 
 // Build implements OPT building for interface RData
-func (o *Opt) Build(_ *bytes.Buffer, _ *Domains) error {
+func (o *Opt) Build(buf *bytes.Buffer, _ *Domains) error {
+	buf.Write(o.optionBytes)
 	return nil
 }
 
-// DefaultOpt returns a standard OPT record
+// buildOptions returns the options in wire format, in the order of o.Options
+func (o *Opt) buildOptions() ([]byte, error) {
+	var options, value bytes.Buffer
+	for _, option := range o.Options {
+		value.Reset()
+		if err := option.Build(&value); err != nil {
+			return nil, fmt.Errorf("unable to build OPT option %d: %w", option.Code(), err)
+		}
+		if value.Len() > 0xffff {
+			return nil, fmt.Errorf("OPT option %d too long: %d", option.Code(), value.Len())
+		}
+		binary.Write(&options, binary.BigEndian, option.Code())
+		binary.Write(&options, binary.BigEndian, uint16(value.Len()))
+		options.Write(value.Bytes())
+	}
+	return options.Bytes(), nil
+}
+
+// Option returns the first option with the given code if present
+func (o *Opt) Option(code uint16) (EDNSOption, bool) {
+	for _, option := range o.Options {
+		if option.Code() == code {
+			return option, true
+		}
+	}
+	return nil, false
+}
+
+// SetOption adds option to the OPT record of m, replacing the options with the
+// same code. An OPT record with DefaultEDNSSize is added if m has none.
+func (m *Message) SetOption(option EDNSOption) {
+	if opt := m.Opt(); opt != nil {
+		options := opt.Options[:0]
+		for _, o := range opt.Options {
+			if o.Code() != option.Code() {
+				options = append(options, o)
+			}
+		}
+		opt.Options = options
+	}
+	m.AddOption(option)
+}
+
+// AddOption appends option to the OPT record of m, keeping options with the
+// same code. An OPT record with DefaultEDNSSize is added if m has none.
+func (m *Message) AddOption(option EDNSOption) {
+	opt := m.addOpt()
+	opt.Options = append(opt.Options, option)
+}
+
+// addOpt returns the OPT record of m, adding one with DefaultEDNSSize if m has
+// none
+func (m *Message) addOpt() *Opt {
+	if opt := m.Opt(); opt != nil {
+		return opt
+	}
+	r := DefaultOpt(DefaultEDNSSize)
+	m.Additional = append(m.Additional, *r)
+	return r.Data.(*Opt)
+}
+
+// DefaultOpt returns a standard OPT record. Opt.Record points at the returned
+// record, not at copies of it, see Opt.
 func DefaultOpt(size int) *Record {
 	r := &Record{
 		Name: "",
@@ -74,3 +179,229 @@ func DefaultOpt(size int) *Record {
 
 // TransformName satisfies the interface
 func (*Opt) TransformName(name string) string { return name }
+
+// String returns the RDATA in the generic presentation format, as OPT has no
+// presentation format of its own
+func (o *Opt) String() string {
+	options, err := o.buildOptions()
+	if err != nil {
+		return genericString(nil)
+	}
+	return genericString(options)
+}
+
+func newEDNSOption(code uint16) EDNSOption {
+	switch code {
+	case OptionCodeNSID:
+		return &EDNSNSID{}
+	case OptionCodeClientSubnet:
+		return &EDNSClientSubnet{}
+	case OptionCodeExpire:
+		return &EDNSExpire{}
+	case OptionCodeCookie:
+		return &EDNSCookie{}
+	case OptionCodeTCPKeepalive:
+		return &EDNSTCPKeepalive{}
+	case OptionCodePadding:
+		return &EDNSPadding{}
+	case OptionCodeChain:
+		return &EDNSChain{}
+	case OptionCodeExtendedError:
+		return &EDNSExtendedError{}
+	default:
+		return &EDNSOpaque{OptionCode: code}
+	}
+}
+
+// EDNSNSID holds the name server identifier (RFC 5001). Queries ask for it
+// with an empty ID.
+type EDNSNSID struct {
+	ID []byte
+}
+
+// Code returns the option code of the option
+func (*EDNSNSID) Code() uint16 { return OptionCodeNSID }
+
+// Parse implements parsing of the option data
+func (e *EDNSNSID) Parse(b []byte) error {
+	e.ID = append([]byte(nil), b...)
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSNSID) Build(buf *bytes.Buffer) error {
+	buf.Write(e.ID)
+	return nil
+}
+
+// EDNSExpire holds the expire timer of a zone in seconds, for zone transfers
+// (RFC 7314). Queries carry the option without a value, which sets Empty.
+type EDNSExpire struct {
+	Expire uint32
+	Empty  bool
+}
+
+// Code returns the option code of the option
+func (*EDNSExpire) Code() uint16 { return OptionCodeExpire }
+
+// Parse implements parsing of the option data
+func (e *EDNSExpire) Parse(b []byte) error {
+	switch len(b) {
+	case 0:
+		e.Expire, e.Empty = 0, true
+	case 4:
+		e.Expire, e.Empty = binary.BigEndian.Uint32(b), false
+	default:
+		return fmt.Errorf("invalid expire length: %d", len(b))
+	}
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSExpire) Build(buf *bytes.Buffer) error {
+	if e.Empty {
+		return nil
+	}
+	return binary.Write(buf, binary.BigEndian, e.Expire)
+}
+
+// EDNSCookie holds a DNS cookie (RFC 7873). The server cookie is empty until
+// the client has learned one from a server.
+type EDNSCookie struct {
+	Client [8]byte
+	Server []byte
+}
+
+// Code returns the option code of the option
+func (*EDNSCookie) Code() uint16 { return OptionCodeCookie }
+
+// Parse implements parsing of the option data
+func (e *EDNSCookie) Parse(b []byte) error {
+	if len(b) != 8 && (len(b) < 16 || len(b) > 40) {
+		return fmt.Errorf("invalid cookie length: %d", len(b))
+	}
+	copy(e.Client[:], b)
+	e.Server = nil
+	if len(b) > 8 {
+		e.Server = append([]byte(nil), b[8:]...)
+	}
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSCookie) Build(buf *bytes.Buffer) error {
+	if len(e.Server) != 0 && (len(e.Server) < 8 || len(e.Server) > 32) {
+		return fmt.Errorf("invalid server cookie length: %d", len(e.Server))
+	}
+	buf.Write(e.Client[:])
+	buf.Write(e.Server)
+	return nil
+}
+
+// EDNSTCPKeepalive holds the idle timeout of TCP connections in units of 100
+// milliseconds (RFC 7828). Queries carry the option without a value, which
+// sets Empty.
+type EDNSTCPKeepalive struct {
+	Timeout uint16
+	Empty   bool
+}
+
+// Code returns the option code of the option
+func (*EDNSTCPKeepalive) Code() uint16 { return OptionCodeTCPKeepalive }
+
+// Parse implements parsing of the option data
+func (e *EDNSTCPKeepalive) Parse(b []byte) error {
+	switch len(b) {
+	case 0:
+		e.Timeout, e.Empty = 0, true
+	case 2:
+		e.Timeout, e.Empty = binary.BigEndian.Uint16(b), false
+	default:
+		return fmt.Errorf("invalid tcp keepalive length: %d", len(b))
+	}
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSTCPKeepalive) Build(buf *bytes.Buffer) error {
+	if e.Empty {
+		return nil
+	}
+	return binary.Write(buf, binary.BigEndian, e.Timeout)
+}
+
+// EDNSPadding pads a message with Length zero bytes to hide its size (RFC
+// 7830)
+type EDNSPadding struct {
+	Length uint16
+}
+
+// Code returns the option code of the option
+func (*EDNSPadding) Code() uint16 { return OptionCodePadding }
+
+// Parse implements parsing of the option data. The padding is not required to
+// be zero, so its content is ignored.
+func (e *EDNSPadding) Parse(b []byte) error {
+	e.Length = uint16(len(b))
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSPadding) Build(buf *bytes.Buffer) error {
+	buf.Write(make([]byte, e.Length))
+	return nil
+}
+
+// EDNSChain asks for the DNSSEC chain of the answer up to the closest trust
+// point known to the client (RFC 7901)
+type EDNSChain struct {
+	ClosestTrustPoint string
+}
+
+// Code returns the option code of the option
+func (*EDNSChain) Code() uint16 { return OptionCodeChain }
+
+// Parse implements parsing of the option data, which is an uncompressed name.
+// Compression pointers are rejected, as nothing precedes the name.
+func (e *EDNSChain) Parse(b []byte) error {
+	name, end, err := unpackName(b, 0)
+	if err != nil {
+		return err
+	}
+	if end != len(b) {
+		return fmt.Errorf("trailing data after chain name: %d bytes", len(b)-end)
+	}
+	e.ClosestTrustPoint = name
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSChain) Build(buf *bytes.Buffer) error {
+	name, err := buildName(e.ClosestTrustPoint, nil)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(name)
+	return nil
+}
+
+// EDNSOpaque holds the raw data of an option without a specific implementation
+type EDNSOpaque struct {
+	OptionCode uint16
+	Data       []byte
+}
+
+// Code returns the option code of the option
+func (e *EDNSOpaque) Code() uint16 { return e.OptionCode }
+
+// Parse implements parsing of the option data
+func (e *EDNSOpaque) Parse(b []byte) error {
+	e.Data = append([]byte(nil), b...)
+	return nil
+}
+
+// Build implements building of the option data
+func (e *EDNSOpaque) Build(buf *bytes.Buffer) error {
+	buf.Write(e.Data)
+	return nil
+}
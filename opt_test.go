@@ -2,10 +2,44 @@ package dns
 
 import (
 	"bytes"
+	"net/netip"
 	"reflect"
 	"testing"
 )
 
+// optOptionsWire holds the options of optOptions in wire format
+var optOptionsWire = []byte("" +
+	"\x00\x03\x00\x00" + // NSID request
+	"\x00\x08\x00\x07\x00\x01\x18\x00\xc0\x00\x02" + // client subnet 192.0.2.0/24/0
+	"\x00\x08\x00\x0b\x00\x02\x38\x30\x20\x01\x0d\xb8\x00\x01\x00" + // 2001:db8:1::/56/48
+	"\x00\x09\x00\x04\x00\x00\x0e\x10" + // expire 3600
+	"\x00\x0a\x00\x10\x01\x02\x03\x04\x05\x06\x07\x08\x11\x12\x13\x14\x15\x16\x17\x18" +
+	"\x00\x0b\x00\x00" + // TCP keepalive request
+	"\x00\x0b\x00\x02\x01\x2c" + // TCP keepalive 30 seconds
+	"\x00\x0c\x00\x03\x00\x00\x00" + // padding
+	"\x00\x0d\x00\x0d\x07example\x03com\x00" + // chain
+	"\x00\x0f\x00\x09\x00\x12blocked" + // extended error
+	"\xfd\xe9\x00\x01\xab") // unknown option
+
+func optOptions() []EDNSOption {
+	return []EDNSOption{
+		&EDNSNSID{},
+		&EDNSClientSubnet{Family: FamilyIPv4, SourcePrefix: 24,
+			Address: netip.MustParseAddr("192.0.2.0")},
+		&EDNSClientSubnet{Family: FamilyIPv6, SourcePrefix: 56, ScopePrefix: 48,
+			Address: netip.MustParseAddr("2001:db8:1::")},
+		&EDNSExpire{Expire: 3600},
+		&EDNSCookie{Client: [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Server: []byte{0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18}},
+		&EDNSTCPKeepalive{Empty: true},
+		&EDNSTCPKeepalive{Timeout: 300},
+		&EDNSPadding{Length: 3},
+		&EDNSChain{ClosestTrustPoint: "example.com"},
+		&EDNSExtendedError{InfoCode: 18, ExtraText: "blocked"},
+		&EDNSOpaque{OptionCode: 65001, Data: []byte{0xab}},
+	}
+}
+
 func TestOpt_Parse(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -23,7 +57,6 @@ func TestOpt_Parse(t *testing.T) {
 			buf: []byte{},
 			want: &Opt{
 				UDPSize: 512,
-				Options: map[uint16][]byte{},
 			},
 		},
 		{
@@ -38,7 +71,6 @@ func TestOpt_Parse(t *testing.T) {
 				RCode:       0xb2,
 				EDNSVersion: 0x03,
 				DNSSec:      true,
-				Options:     map[uint16][]byte{},
 			},
 		},
 		{
@@ -50,9 +82,48 @@ func TestOpt_Parse(t *testing.T) {
 			buf: []byte("\x00\x05\x00\x02\xab\xab"),
 			want: &Opt{
 				UDPSize: 512,
-				Options: map[uint16][]byte{5: []byte("\xab\xab")},
+				Options: []EDNSOption{&EDNSOpaque{OptionCode: 5, Data: []byte("\xab\xab")}},
+			},
+		},
+		{
+			name:   "Typed options keep order and repeated codes",
+			record: &Record{Class: 1232, Length: uint16(len(optOptionsWire))},
+			buf:    optOptionsWire,
+			want: &Opt{
+				UDPSize: 1232,
+				Options: optOptions(),
 			},
 		},
+		{
+			name:    "Option longer than RDATA",
+			record:  &Record{Class: 512, Length: 6},
+			buf:     []byte("\x00\x05\x00\x03\xab\xab"),
+			wantErr: true,
+		},
+		{
+			name:    "Truncated option header",
+			record:  &Record{Class: 512, Length: 3},
+			buf:     []byte("\x00\x05\x00"),
+			wantErr: true,
+		},
+		{
+			name:    "Bad cookie length",
+			record:  &Record{Class: 512, Length: 8},
+			buf:     []byte("\x00\x0a\x00\x04\x01\x02\x03\x04"),
+			wantErr: true,
+		},
+		{
+			name:    "Compressed chain name",
+			record:  &Record{Class: 512, Length: 6},
+			buf:     []byte("\x00\x0d\x00\x02\xc0\x00"),
+			wantErr: true,
+		},
+		{
+			name:    "Client subnet address longer than prefix",
+			record:  &Record{Class: 512, Length: 11},
+			buf:     []byte("\x00\x08\x00\x07\x00\x01\x08\x00\xc0\x00\x02"),
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -61,6 +132,9 @@ func TestOpt_Parse(t *testing.T) {
 				t.Errorf("Opt.Parse() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if tt.wantErr {
+				return
+			}
 			tt.want.Record = tt.record
 			if !reflect.DeepEqual(o, tt.want) {
 				t.Errorf("Opt.Parse() = %v, want %v", o, tt.want)
@@ -140,3 +214,84 @@ func TestDefaultOpt(t *testing.T) {
 		})
 	}
 }
+
+func TestOpt_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []EDNSOption
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "No options",
+			want: []byte{},
+		},
+		{
+			name:    "Typed options",
+			options: optOptions(),
+			want:    optOptionsWire,
+		},
+		{
+			name: "Client subnet address is truncated to prefix",
+			options: []EDNSOption{&EDNSClientSubnet{Family: FamilyIPv4, SourcePrefix: 20,
+				Address: netip.MustParseAddr("198.51.96.0")}},
+			want: []byte("\x00\x08\x00\x07\x00\x01\x14\x00\xc6\x33\x60"),
+		},
+		{
+			name: "Client subnet family mismatch",
+			options: []EDNSOption{&EDNSClientSubnet{Family: FamilyIPv6, SourcePrefix: 24,
+				Address: netip.MustParseAddr("192.0.2.0")}},
+			wantErr: true,
+		},
+		{
+			name:    "Server cookie too short",
+			options: []EDNSOption{&EDNSCookie{Server: []byte{1, 2, 3}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := DefaultOpt(1232)
+			r.Data.(*Opt).Options = tt.options
+			buf := new(bytes.Buffer)
+			err := r.Build(buf, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Record.Build() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := buf.Bytes()[11:]; !bytes.Equal(got, tt.want) {
+				t.Errorf("Opt.Build() = %x, want %x", got, tt.want)
+			}
+			if got := int(r.Length); got != len(tt.want) {
+				t.Errorf("Opt.PreBuild() length = %v, want %v", got, len(tt.want))
+			}
+
+			parsed, err := ParseRecord(bytes.NewBuffer(buf.Bytes()), 0, nil)
+			if err != nil {
+				t.Errorf("ParseRecord() error = %v", err)
+				return
+			}
+			if got := parsed.Data.(*Opt).Options; len(tt.options) > 0 &&
+				!reflect.DeepEqual(got, tt.options) {
+				t.Errorf("ParseRecord() options = %v, want %v", got, tt.options)
+			}
+		})
+	}
+}
+
+func TestMessage_SetOption(t *testing.T) {
+	m := &Message{}
+	m.SetOption(&EDNSNSID{})
+	m.SetOption(&EDNSPadding{Length: 8})
+	m.SetOption(&EDNSNSID{ID: []byte("ns1")})
+	if len(m.Additional) != 1 {
+		t.Fatalf("Message.SetOption() added %d records, want 1", len(m.Additional))
+	}
+	want := []EDNSOption{&EDNSPadding{Length: 8}, &EDNSNSID{ID: []byte("ns1")}}
+	if got := m.Opt().Options; !reflect.DeepEqual(got, want) {
+		t.Errorf("Message.SetOption() options = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,178 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Unpack parses the DNS message in msg. Unlike ParseMessage it works on offsets
+// into msg, resolving compressed names by reading them where the pointers
+// lead instead of keeping a map of all names seen. msg must not be modified
+// while Unpack runs, but is not referenced by the returned message.
+func Unpack(msg []byte) (*Message, error) {
+	if len(msg) < HdrLength {
+		return nil, io.ErrUnexpectedEOF
+	}
+	m := &Message{ID: binary.BigEndian.Uint16(msg)}
+	m.parseOpts(binary.BigEndian.Uint16(msg[2:]))
+	m.qdcount = binary.BigEndian.Uint16(msg[4:])
+	m.ancount = binary.BigEndian.Uint16(msg[6:])
+	m.nscount = binary.BigEndian.Uint16(msg[8:])
+	m.arcount = binary.BigEndian.Uint16(msg[10:])
+
+	domains := &Domains{msg: msg}
+	off := HdrLength
+	var err error
+	if m.qdcount > 0 {
+		m.Questions = make([]Question, 0, m.qdcount)
+	}
+	for i := 0; i < int(m.qdcount); i++ {
+		var q Question
+		if q, off, err = unpackQuestion(msg, off); err != nil {
+			return nil, fmt.Errorf("unable to parse questions: %w", err)
+		}
+		m.Questions = append(m.Questions, q)
+	}
+	for _, section := range []struct {
+		name    string
+		count   uint16
+		records *[]Record
+	}{
+		{"answers", m.ancount, &m.Answers},
+		{"nameservers", m.nscount, &m.Nameservers},
+		{"additionals", m.arcount, &m.Additional},
+	} {
+		if section.count > 0 {
+			*section.records = make([]Record, 0, section.count)
+		}
+		for i := 0; i < int(section.count); i++ {
+			var r Record
+			if r, off, err = unpackRecord(msg, off, domains); err != nil {
+				return nil, fmt.Errorf("unable to parse %s: %w", section.name, err)
+			}
+			*section.records = append(*section.records, r)
+		}
+	}
+	return m, nil
+}
+
+// Pack builds m into a new byte slice
+func (m *Message) Pack() ([]byte, error) {
+	return m.PackTo(nil)
+}
+
+// PackTo builds m into the memory of b, which is overwritten, and returns the
+// message. A new slice is allocated if the message does not fit in b, so a
+// buffer can be reused for building many messages.
+func (m *Message) PackTo(b []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(b[:0])
+	domains := AcquireDomains()
+	defer domains.Release()
+	if err := m.Build(buf, domains); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unpackQuestion(msg []byte, off int) (Question, int, error) {
+	var q Question
+	var err error
+	if q.Domain, off, err = unpackName(msg, off); err != nil {
+		return Question{}, off, err
+	}
+	if off+4 > len(msg) {
+		return Question{}, off, io.ErrUnexpectedEOF
+	}
+	q.Type = Type(binary.BigEndian.Uint16(msg[off:]))
+	class := binary.BigEndian.Uint16(msg[off+2:])
+	q.UnicastResponse = class&UnicastResponseBit == UnicastResponseBit
+	q.Class = Class(class & 0x7fff)
+	return q, off + 4, nil
+}
+
+func unpackRecord(msg []byte, off int, domains *Domains) (Record, int, error) {
+	var r Record
+	var err error
+	if r.Name, off, err = unpackName(msg, off); err != nil {
+		return Record{}, off, err
+	}
+	if off+10 > len(msg) {
+		return Record{}, off, io.ErrUnexpectedEOF
+	}
+	r.Type = Type(binary.BigEndian.Uint16(msg[off:]))
+	r.Class = binary.BigEndian.Uint16(msg[off+2:])
+	r.TTL = binary.BigEndian.Uint32(msg[off+4:])
+	r.Length = binary.BigEndian.Uint16(msg[off+8:])
+	off += 10
+	r.CacheFlush = r.Class&CacheFlushBit == CacheFlushBit
+	r.Class &= 0x7fff
+
+	end := off + int(r.Length)
+	if end > len(msg) {
+		return Record{}, off, io.ErrUnexpectedEOF
+	}
+	// The RDATA is parsed from its own buffer, so it can not be read past
+	if err := r.parseRData(bytes.NewBuffer(msg[off:end:end]), off, domains); err != nil {
+		return Record{}, off, err
+	}
+	return r, end, nil
+}
+
+// unpackName reads the name at off in msg, following compression pointers
+// within msg. It returns the name and the offset following it. Each pointer
+// must point before the labels it follows, which rules out loops.
+func unpackName(msg []byte, off int) (string, int, error) {
+	var b strings.Builder
+	end := -1
+	limit := off
+	wireLength := 1
+	for {
+		if off >= len(msg) {
+			return "", off, io.ErrUnexpectedEOF
+		}
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return b.String(), end, nil
+		case length&NamePointer == NamePointer:
+			if off+1 >= len(msg) {
+				return "", off, io.ErrUnexpectedEOF
+			}
+			ptr := int(binary.BigEndian.Uint16(msg[off:])) & PointerMask
+			if ptr >= limit {
+				return "", off, fmt.Errorf("%w: pointer %d at offset %d", ErrPointerLoop, ptr,
+					off)
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off, limit = ptr, ptr
+		case length > maxLabelLength:
+			return "", off, fmt.Errorf("%w: length %d", ErrLabelTooLong, length)
+		default:
+			wireLength += 1 + length
+			if wireLength > maxNameLength {
+				return "", off, ErrNameTooLong
+			}
+			if off+1+length > len(msg) {
+				return "", off, io.ErrUnexpectedEOF
+			}
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			label := msg[off+1 : off+1+length]
+			if needsEscape(label) {
+				b.WriteString(escapeLabel(label))
+			} else {
+				b.Write(label)
+			}
+			off += 1 + length
+		}
+	}
+}
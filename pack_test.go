@@ -0,0 +1,132 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/netip"
+	"testing"
+)
+
+// testMessage returns a response with compressed names in owner names and
+// RDATA of several types
+func testMessage() *Message {
+	rr := func(name string, typ Type, data RData) Record {
+		return Record{Name: name, Type: typ, Class: uint16(IN), TTL: 300, Data: data}
+	}
+	m := ReplyTo(&Message{ID: 0x1234, Questions: []Question{
+		{Domain: "www.example.com", Type: A, Class: IN},
+	}})
+	m.RD, m.RA = true, true
+	m.Answers = []Record{
+		rr("www.example.com", CNAME, &CName{Name: "web.example.com"}),
+		rr("web.example.com", A, &IPv4{netip.MustParseAddr("192.0.2.1")}),
+		rr("web.example.com", AAAA, &IPv6{netip.MustParseAddr("2001:db8::1")}),
+		rr("example.com", MX, &Mx{Preference: 10, Exchange: "mail.example.com"}),
+		rr("example.com", TXT, &Txt{Data: []string{"v=spf1 -all", "second"}}),
+		rr(`My Printer v2\.1._ipp._tcp.local`, SRV, &Srv{Priority: 1, Weight: 2, Port: 631,
+			Target: "printer.local", Identifier: `My Printer v2\.1`, Service: "_ipp",
+			Proto: "_tcp", Name: "local"}),
+	}
+	m.Nameservers = []Record{
+		rr("example.com", SOA, &Soa{MName: "ns1.example.com", RName: "hostmaster.example.com",
+			Serial: 1, Refresh: 7200, Retry: 3600, Expire: 1209600, Minimum: 300}),
+		rr("example.com", NS, &Ns{Name: "ns1.example.com"}),
+	}
+	m.Additional = []Record{*DefaultOpt(1232)}
+	return m
+}
+
+func TestUnpack(t *testing.T) {
+	msg, err := testMessage().Pack()
+	if err != nil {
+		t.Fatalf("Message.Pack() error = %v", err)
+	}
+	want, err := ParseMessage(bytes.NewBuffer(msg))
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	got, err := Unpack(msg)
+	if err != nil {
+		t.Fatalf("Unpack() error = %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("Unpack() =\n%s\nwant\n%s", got, want)
+	}
+	if got.String() != testMessage().String() {
+		t.Errorf("Unpack() =\n%s\nwant\n%s", got, testMessage())
+	}
+	repacked, err := got.Pack()
+	if err != nil {
+		t.Fatalf("Message.Pack() error = %v", err)
+	}
+	if !bytes.Equal(repacked, msg) {
+		t.Errorf("Message.Pack() of unpacked message = %x, want %x", repacked, msg)
+	}
+}
+
+func TestUnpack_Malformed(t *testing.T) {
+	header := "\x00\x01\x81\x80\x00\x01\x00\x01\x00\x00\x00\x00"
+	question := "\x07example\x03com\x00\x00\x01\x00\x01"
+	tests := []struct {
+		name    string
+		msg     string
+		wantErr error
+	}{
+		{name: "Short header", msg: header[:10], wantErr: io.ErrUnexpectedEOF},
+		{name: "Missing question", msg: header, wantErr: io.ErrUnexpectedEOF},
+		{name: "Question pointer loop", msg: header + "\xc0\x0c\x00\x01\x00\x01",
+			wantErr: ErrPointerLoop},
+		{name: "Forward pointer", msg: header + "\x03www\xc0\x20\x00\x01\x00\x01",
+			wantErr: ErrPointerLoop},
+		{name: "Label too long", msg: header + "\x40", wantErr: ErrLabelTooLong},
+		{name: "RDATA past end", msg: header + question +
+			"\xc0\x0c\x00\x01\x00\x01\x00\x00\x01\x2c\x00\x08\xc0\x00\x02\x01",
+			wantErr: io.ErrUnexpectedEOF},
+		{name: "Pointer loop in RDATA", msg: header + question +
+			"\xc0\x0c\x00\x05\x00\x01\x00\x00\x01\x2c\x00\x02\xc0\x29",
+			wantErr: ErrPointerLoop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Unpack([]byte(tt.msg)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Unpack() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMessage_PackTo(t *testing.T) {
+	m := testMessage()
+	want, err := m.Pack()
+	if err != nil {
+		t.Fatalf("Message.Pack() error = %v", err)
+	}
+	b := make([]byte, 0, 4096)
+	got, err := m.PackTo(b[:10])
+	if err != nil {
+		t.Fatalf("Message.PackTo() error = %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Message.PackTo() = %x, want %x", got, want)
+	}
+	if &got[0] != &b[:1][0] {
+		t.Error("Message.PackTo() did not reuse the memory of b")
+	}
+}
+
+func BenchmarkUnpack(b *testing.B) {
+	msg, _ := testMessage().Pack()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Unpack(msg)
+	}
+}
+
+func BenchmarkParseMessage(b *testing.B) {
+	msg, _ := testMessage().Pack()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseMessage(bytes.NewBuffer(msg))
+	}
+}
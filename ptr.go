@@ -2,7 +2,7 @@ package dns
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
 )
 
 // Ptr implements interface RData
@@ -15,7 +15,7 @@ type Ptr struct {
 func (n *Ptr) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
 	name, err := ParseName(buf, ptr, domains)
 	if err != nil {
-		return errors.New("unable to parse Ptr: " + err.Error())
+		return fmt.Errorf("unable to parse Ptr: %w", err)
 	}
 	n.Name = name
 	return nil
@@ -30,9 +30,24 @@ func (n *Ptr) Build(buf *bytes.Buffer, domains *Domains) error {
 
 // PreBuild implements Ptr pre building for interface RData
 func (n *Ptr) PreBuild(_ *Record, domains *Domains) (int, error) {
-	n.bytes = BuildName(n.Name, domains)
+	var err error
+	if n.bytes, err = buildName(n.Name, domains); err != nil {
+		return 0, err
+	}
 	return len(n.bytes), nil
 }
 
 // TransformName satisfies the interface
 func (*Ptr) TransformName(name string) string { return name }
+
+// parseText implements presentation format parsing of PTR records
+func (n *Ptr) parseText(t *rdataText) error {
+	name, err := t.name("name")
+	n.Name = name
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (n *Ptr) String() string { return n.format(FormatOptions{}) }
+
+func (n *Ptr) format(opts FormatOptions) string { return opts.name(n.Name) }
@@ -45,10 +45,13 @@ func (q *Question) Build(buf *bytes.Buffer, domains *Domains) error {
 		return errors.New("domain or query type unset")
 	}
 
-	name := BuildName(q.Domain, domains)
+	name, err := buildName(q.Domain, domains)
+	if err != nil {
+		return err
+	}
 	domains.SetBuild(buf.Len(), q.Domain)
 	buf.WriteString(name)
-	err := binary.Write(buf, binary.BigEndian, q.Type)
+	err = binary.Write(buf, binary.BigEndian, q.Type)
 	if err != nil {
 		return err
 	}
@@ -134,6 +134,25 @@ func TestQuestion_Build(t *testing.T) {
 	}
 }
 
+func TestQuestion_String(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Question
+		want string
+	}{
+		{name: "A question", q: Question{Domain: "example.com", Type: A, Class: IN}, want: "example.com.\tIN\tA"},
+		{name: "Root", q: Question{Domain: "", Type: NS, Class: IN}, want: ".\tIN\tNS"},
+		{name: "Generic type", q: Question{Domain: "example.com", Type: 731, Class: CH}, want: "example.com.\tCH\tTYPE731"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.String(); got != tt.want {
+				t.Errorf("Question.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func BenchmarkQuestionParsing(b *testing.B) {
 	buf := []byte("\x06domain\x04test\x00\x01\x00\x01")
 	for i := 0; i < b.N; i++ {
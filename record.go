@@ -3,7 +3,6 @@ package dns
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
 )
 
 // CacheFlushBit holds the bit for the mDNS cache flush instruction
@@ -63,6 +62,14 @@ func ParseRecord(buf *bytes.Buffer, ptr int, domains *Domains) (Record, error) {
 }
 
 func (r *Record) parseRData(buf *bytes.Buffer, ptr int, domains *Domains) error {
+	rdata := r.newRData()
+	err := rdata.Parse(buf, ptr, domains)
+	r.Data = rdata
+	return err
+}
+
+// newRData returns the empty RData implementation for the type of the record
+func (r *Record) newRData() RData {
 	var rdata RData
 	switch r.Type {
 	case A:
@@ -75,22 +82,43 @@ func (r *Record) parseRData(buf *bytes.Buffer, ptr int, domains *Domains) error
 		rdata = &CName{}
 	case PTR:
 		rdata = &Ptr{}
+	case NS:
+		rdata = &Ns{}
+	case MX:
+		rdata = &Mx{}
+	case SOA:
+		rdata = &Soa{}
 	case SRV:
 		rdata = &Srv{NameBytes: r.Name}
 	case TXT:
 		rdata = &Txt{Length: r.Length}
+	case SVCB, HTTPS:
+		rdata = &Svcb{length: r.Length}
+	case DNSKEY, CDNSKEY:
+		rdata = &Dnskey{length: r.Length}
+	case DS, CDS:
+		rdata = &Ds{length: r.Length}
+	case RRSIG:
+		rdata = &Rrsig{length: r.Length}
+	case NSEC:
+		rdata = &Nsec{length: r.Length}
+	case NSEC3:
+		rdata = &Nsec3{length: r.Length}
+	case NSEC3PARAM:
+		rdata = &Nsec3Param{length: r.Length}
 	default:
-		return errors.New("type not supported: " + RRTypeStrings[r.Type])
+		rdata = &Unknown{length: r.Length}
 	}
-	err := rdata.Parse(buf, ptr, domains)
-	r.Data = rdata
-	return err
+	return rdata
 }
 
 // Build is the generic entry to building all records
 func (r *Record) Build(buf *bytes.Buffer, domains *Domains) error {
 	r.Name = r.Data.TransformName(r.Name)
-	name := BuildName(r.Name, domains)
+	name, err := buildName(r.Name, domains)
+	if err != nil {
+		return err
+	}
 	domains.SetBuild(buf.Len(), r.Name)
 	buf.WriteString(name)
 	length, err := r.Data.PreBuild(r, domains)
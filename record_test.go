@@ -67,11 +67,26 @@ func TestParseRecord(t *testing.T) {
 			},
 		},
 		{
-			name: "Bad record type",
+			name: "Unknown record type",
 			args: args{
 				buf:     []byte("\x06golang\x03com\x00\x00\x77\x00\x01\x00\x00\x01\x2c\x00\x04\x8e\xfb\x29\x51"),
 				domains: NewDomains(),
 			},
+			want: Record{
+				Name:   "golang.com",
+				Type:   119,
+				Class:  1,
+				TTL:    300,
+				Length: 4,
+				Data:   &Unknown{Data: []byte("\x8e\xfb\x29\x51"), length: 4},
+			},
+		},
+		{
+			name: "Unknown record type with short RDATA",
+			args: args{
+				buf:     []byte("\x06golang\x03com\x00\x00\x77\x00\x01\x00\x00\x01\x2c\x00\x04\x8e\xfb"),
+				domains: NewDomains(),
+			},
 			wantErr: true,
 		},
 	}
@@ -185,6 +200,54 @@ func TestRecord_Build(t *testing.T) {
 			},
 			want: []byte("\x0f_acme-challenge\x06tester\x09ipv6check\x02me\x00\x00\x05\x00\x01\x00\x00\x01\x2c\x00\x17\x0f_acme-challenge\x04acme\xc0\x17"),
 		},
+		{
+			name: "Build MX record with name pointer",
+			args: args{domains: NewDomains()},
+			fields: fields{
+				TTL:   300,
+				Class: 1,
+				Type:  MX,
+				Name:  "golang.com",
+				Data:  &Mx{Preference: 10, Exchange: "mail.golang.com"},
+			},
+			want: []byte("\x06golang\x03com\x00\x00\x0f\x00\x01\x00\x00\x01\x2c\x00\x09\x00\x0a\x04mail\xc0\x00"),
+		},
+		{
+			name: "Build NS record with name pointer",
+			args: args{domains: NewDomains()},
+			fields: fields{
+				TTL:   300,
+				Class: 1,
+				Type:  NS,
+				Name:  "golang.com",
+				Data:  &Ns{Name: "ns1.golang.com"},
+			},
+			want: []byte("\x06golang\x03com\x00\x00\x02\x00\x01\x00\x00\x01\x2c\x00\x06\x03ns1\xc0\x00"),
+		},
+		{
+			name: "Build HTTPS record without target compression",
+			args: args{domains: NewDomains()},
+			fields: fields{
+				TTL:   300,
+				Class: 1,
+				Type:  HTTPS,
+				Name:  "golang.com",
+				Data:  &Svcb{Target: "golang.com"},
+			},
+			want: []byte("\x06golang\x03com\x00\x00\x41\x00\x01\x00\x00\x01\x2c\x00\x0e\x00\x00\x06golang\x03com\x00"),
+		},
+		{
+			name: "Build Unknown record",
+			args: args{domains: NewDomains()},
+			fields: fields{
+				TTL:   300,
+				Class: 1,
+				Type:  119,
+				Name:  "golang.com",
+				Data:  &Unknown{Data: []byte("\x8e\xfb\x29\x51")},
+			},
+			want: []byte("\x06golang\x03com\x00\x00\x77\x00\x01\x00\x00\x01\x2c\x00\x04\x8e\xfb\x29\x51"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -209,6 +272,178 @@ func TestRecord_Build(t *testing.T) {
 	}
 }
 
+func TestRecord_String(t *testing.T) {
+	rr := func(name string, typ Type, data RData) Record {
+		return Record{Name: name, Type: typ, Class: uint16(IN), TTL: 300, Data: data}
+	}
+	tests := []struct {
+		name   string
+		record Record
+		want   string
+	}{
+		{
+			name:   "A",
+			record: rr("example.com", A, &IPv4{netip.MustParseAddr("192.0.2.1")}),
+			want:   "example.com.\t300\tIN\tA\t192.0.2.1",
+		},
+		{
+			name:   "AAAA",
+			record: rr("example.com", AAAA, &IPv6{netip.MustParseAddr("2001:db8::1")}),
+			want:   "example.com.\t300\tIN\tAAAA\t2001:db8::1",
+		},
+		{
+			name:   "CNAME",
+			record: rr("www.example.com", CNAME, &CName{Name: "example.com"}),
+			want:   "www.example.com.\t300\tIN\tCNAME\texample.com.",
+		},
+		{
+			name:   "PTR to root",
+			record: rr("1.2.0.192.in-addr.arpa", PTR, &Ptr{Name: ""}),
+			want:   "1.2.0.192.in-addr.arpa.\t300\tIN\tPTR\t.",
+		},
+		{
+			name:   "PTR with escaped labels",
+			record: rr("_ipp._tcp.local", PTR, &Ptr{Name: `My Printer v2\.1\000._ipp._tcp.local`}),
+			want:   "_ipp._tcp.local.\t300\tIN\tPTR\tMy\\032Printer\\032v2\\.1\\000._ipp._tcp.local.",
+		},
+		{
+			name:   "PTR with UTF-8 mDNS name",
+			record: rr("_airplay._tcp.local", PTR, &Ptr{Name: "Living Room’s TV._airplay._tcp.local"}),
+			want:   "_airplay._tcp.local.\t300\tIN\tPTR\tLiving\\032Room’s\\032TV._airplay._tcp.local.",
+		},
+		{
+			name:   "PTR with non-printable UTF-8",
+			record: rr("_ipp._tcp.local", PTR, &Ptr{Name: "a\u200bb._ipp._tcp.local"}),
+			want:   "_ipp._tcp.local.\t300\tIN\tPTR\ta\\226\\128\\139b._ipp._tcp.local.",
+		},
+		{
+			name:   "NS",
+			record: rr("example.com", NS, &Ns{Name: "ns1.example.com"}),
+			want:   "example.com.\t300\tIN\tNS\tns1.example.com.",
+		},
+		{
+			name:   "MX",
+			record: rr("example.com", MX, &Mx{Preference: 10, Exchange: "mail.example.com"}),
+			want:   "example.com.\t300\tIN\tMX\t10 mail.example.com.",
+		},
+		{
+			name: "SOA",
+			record: rr("example.com", SOA, &Soa{MName: "ns1.example.com", RName: "hostmaster.example.com",
+				Serial: 2024010101, Refresh: 7200, Retry: 3600, Expire: 1209600, Minimum: 300}),
+			want: "example.com.\t300\tIN\tSOA\tns1.example.com. hostmaster.example.com. 2024010101 7200 3600 1209600 300",
+		},
+		{
+			name: "SRV",
+			record: rr("_sip._tcp.example.com", SRV, &Srv{Priority: 10, Weight: 20, Port: 5060,
+				Target: "sip.example.com"}),
+			want: "_sip._tcp.example.com.\t300\tIN\tSRV\t10 20 5060 sip.example.com.",
+		},
+		{
+			name:   "TXT with escapes",
+			record: rr("example.com", TXT, &Txt{Data: []string{"hello world", `say "hi" \o/`, "\x00\xff"}}),
+			want:   "example.com.\t300\tIN\tTXT\t\"hello world\" \"say \\\"hi\\\" \\\\o/\" \"\\000\\255\"",
+		},
+		{
+			name: "HTTPS",
+			record: rr("example.com", HTTPS, &Svcb{Priority: 1, Params: []SvcParam{
+				&SvcMandatory{Keys: []SvcParamKey{SvcParamAlpn}},
+				&SvcAlpn{IDs: []string{"h2", "a,b"}},
+				&SvcNoDefaultAlpn{},
+				&SvcPort{Port: 8443},
+				&SvcIPv6Hint{Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")}},
+				&SvcOpaque{KeyCode: 667, Value: []byte("x y")},
+			}}),
+			want: "example.com.\t300\tIN\tHTTPS\t1 . mandatory=alpn alpn=\"h2,a\\\\,b\" no-default-alpn port=8443 ipv6hint=2001:db8::1 key667=\"x y\"",
+		},
+		{
+			name: "DNSKEY",
+			record: rr("example.com", DNSKEY, &Dnskey{Flags: 257, Protocol: 3, Algorithm: ED25519,
+				PublicKey: []byte{1, 2, 3}}),
+			want: "example.com.\t300\tIN\tDNSKEY\t257 3 15 AQID",
+		},
+		{
+			name: "DS",
+			record: rr("example.com", DS, &Ds{KeyTag: 3613, Algorithm: ED25519, DigestType: DigestSHA256,
+				Digest: []byte{0xab, 0xcd}}),
+			want: "example.com.\t300\tIN\tDS\t3613 15 2 ABCD",
+		},
+		{
+			name: "RRSIG",
+			record: rr("example.com", RRSIG, &Rrsig{TypeCovered: A, Algorithm: RSASHA256, Labels: 2,
+				OriginalTTL: 300, Expiration: 1048354263, Inception: 1045762263, KeyTag: 2642,
+				SignerName: "example.com", Signature: []byte{1, 2, 3}}),
+			want: "example.com.\t300\tIN\tRRSIG\tA 8 2 300 20030322173103 20030220173103 2642 example.com. AQID",
+		},
+		{
+			name: "NSEC",
+			record: rr("example.com", NSEC, &Nsec{NextDomain: "a.example.com",
+				TypeBitMap: []Type{A, RRSIG, NSEC, 1234}}),
+			want: "example.com.\t300\tIN\tNSEC\ta.example.com. A RRSIG NSEC TYPE1234",
+		},
+		{
+			name: "NSEC3",
+			record: rr("0p9mhaveqvm6t7vbl5lop2u3t2rp3tom.example", NSEC3, &Nsec3{HashAlgorithm: Nsec3SHA1,
+				Flags: Nsec3OptOut, Iterations: 12, Salt: []byte{0xaa, 0xbb},
+				NextHashed: []byte{0x01, 0x02, 0x03, 0x04, 0x05}, TypeBitMap: []Type{A}}),
+			want: "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom.example.\t300\tIN\tNSEC3\t1 1 12 aabb 04106105 A",
+		},
+		{
+			name:   "NSEC3PARAM",
+			record: rr("example", NSEC3PARAM, &Nsec3Param{HashAlgorithm: Nsec3SHA1}),
+			want:   "example.\t300\tIN\tNSEC3PARAM\t1 0 0 -",
+		},
+		{
+			name:   "Unknown type and class",
+			record: Record{Name: "example.com", Type: 731, Class: 32, TTL: 0, Data: &Unknown{Data: []byte{0xab}}},
+			want:   "example.com.\t0\tCLASS32\tTYPE731\t\\# 1 ab",
+		},
+		{
+			name: "OPT",
+			record: Record{Name: "", Type: OPT, Class: 1232, Data: &Opt{
+				Options: []EDNSOption{&EDNSNSID{ID: []byte{1, 2}}},
+			}},
+			want: ".\t0\tCLASS1232\tOPT\t\\# 6 000300020102",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.record.String()
+			if got != tt.want {
+				t.Fatalf("Record.String() = %q, want %q", got, tt.want)
+			}
+			parsed, err := ParseRecordString(got)
+			if err != nil {
+				t.Fatalf("ParseRecordString() error = %v", err)
+			}
+			if parsed.String() != got {
+				t.Errorf("ParseRecordString().String() = %q, want %q", parsed.String(), got)
+			}
+		})
+	}
+}
+
+func TestParseRecordString(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{
+		{name: "Relative to root", in: "www.example.com 60 A 192.0.2.1"},
+		{name: "Two records", in: "a. 60 A 192.0.2.1\nb. 60 A 192.0.2.2", wantErr: true},
+		{name: "Empty", in: "", wantErr: true},
+		{name: "Missing TTL", in: "a. A 192.0.2.1", wantErr: true},
+		{name: "Pointer in generic RDATA", in: `example.com. 300 IN MX \# 4 000ac000`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRecordString(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRecordString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func BenchmarkRecordParsing(b *testing.B) {
 	buf := []byte("\x06golang\x03com\x00\x00\x1c\x00\x01\x00\x00\x01\x2c\x00\x10\x26\x07\xf8\xb0\x40\x0b\x08\x02\x00\x00\x00\x00\x00\x00\x20\x11")
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,137 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// rrsigFixedLength is the length of the RRSIG RDATA before the signer name
+const rrsigFixedLength = 18
+
+// Rrsig implements interface RData
+type Rrsig struct {
+	TypeCovered Type
+	Algorithm   uint8
+	Labels      uint8
+	OriginalTTL uint32
+	Expiration  uint32
+	Inception   uint32
+	KeyTag      uint16
+	SignerName  string
+	Signature   []byte
+	signerBytes string
+	length      uint16
+}
+
+// Parse implements RRSIG parsing for interface RData
+func (s *Rrsig) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
+	bufLen := buf.Len()
+	if bufLen < int(s.length) || s.length < rrsigFixedLength {
+		return fmt.Errorf("unable to read RRSIG: length %d, %d bytes left",
+			s.length, bufLen)
+	}
+	binary.Read(buf, binary.BigEndian, &s.TypeCovered)
+	s.Algorithm, _ = buf.ReadByte()
+	s.Labels, _ = buf.ReadByte()
+	binary.Read(buf, binary.BigEndian, &s.OriginalTTL)
+	binary.Read(buf, binary.BigEndian, &s.Expiration)
+	binary.Read(buf, binary.BigEndian, &s.Inception)
+	binary.Read(buf, binary.BigEndian, &s.KeyTag)
+	name, err := ParseName(buf, ptr+rrsigFixedLength, domains)
+	if err != nil {
+		return fmt.Errorf("unable to parse RRSIG signer name: %w", err)
+	}
+	s.SignerName = name
+	sigLen := int(s.length) - (bufLen - buf.Len())
+	if sigLen < 0 {
+		return fmt.Errorf("RRSIG signer name exceeds RDATA length %d", s.length)
+	}
+	s.Signature = make([]byte, sigLen)
+	copy(s.Signature, buf.Next(sigLen))
+	return nil
+}
+
+// Build implements RRSIG building for interface RData
+func (s *Rrsig) Build(buf *bytes.Buffer, _ *Domains) error {
+	s.buildFixed(buf)
+	buf.WriteString(s.signerBytes)
+	buf.Write(s.Signature)
+	return nil
+}
+
+// PreBuild builds the signer name, which must not be compressed as required
+// by RFC 4034
+func (s *Rrsig) PreBuild(_ *Record, _ *Domains) (int, error) {
+	var err error
+	if s.signerBytes, err = buildName(s.SignerName, nil); err != nil {
+		return 0, err
+	}
+	return rrsigFixedLength + len(s.signerBytes) + len(s.Signature), nil
+}
+
+// TransformName satisfies the interface
+func (*Rrsig) TransformName(name string) string { return name }
+
+func (s *Rrsig) buildFixed(buf *bytes.Buffer) {
+	binary.Write(buf, binary.BigEndian, s.TypeCovered)
+	buf.WriteByte(s.Algorithm)
+	buf.WriteByte(s.Labels)
+	binary.Write(buf, binary.BigEndian, s.OriginalTTL)
+	binary.Write(buf, binary.BigEndian, s.Expiration)
+	binary.Write(buf, binary.BigEndian, s.Inception)
+	binary.Write(buf, binary.BigEndian, s.KeyTag)
+}
+
+// parseText implements presentation format parsing of RRSIG records
+func (s *Rrsig) parseText(t *rdataText) error {
+	covered, err := t.next("type covered")
+	if err != nil {
+		return err
+	}
+	if s.TypeCovered, err = parseType(covered); err != nil {
+		return err
+	}
+	if s.Algorithm, err = t.algorithm(); err != nil {
+		return err
+	}
+	if s.Labels, err = t.uint8("labels"); err != nil {
+		return err
+	}
+	if s.OriginalTTL, err = t.uint32("original TTL"); err != nil {
+		return err
+	}
+	for _, field := range []*uint32{&s.Expiration, &s.Inception} {
+		v, err := t.next("signature time")
+		if err != nil {
+			return err
+		}
+		if *field, err = parseSigTime(v); err != nil {
+			return err
+		}
+	}
+	if s.KeyTag, err = t.uint16("key tag"); err != nil {
+		return err
+	}
+	if s.SignerName, err = t.name("signer name"); err != nil {
+		return err
+	}
+	s.Signature, err = t.base64("signature")
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (s *Rrsig) String() string { return s.format(FormatOptions{}) }
+
+func (s *Rrsig) format(opts FormatOptions) string {
+	return fmt.Sprintf("%s %d %d %d %s %s %d %s %s", s.TypeCovered, s.Algorithm,
+		s.Labels, s.OriginalTTL, sigTimeString(s.Expiration), sigTimeString(s.Inception),
+		s.KeyTag, opts.name(s.SignerName), base64.StdEncoding.EncodeToString(s.Signature))
+}
+
+// sigTimeString returns an RRSIG time in YYYYMMDDHHmmSS form
+func sigTimeString(t uint32) string {
+	return time.Unix(int64(t), 0).UTC().Format("20060102150405")
+}
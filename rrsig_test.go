@@ -0,0 +1,87 @@
+package dns
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+var (
+	rrsigWire = []byte("\x00\x0f\x0f\x02\x00\x00\x0e\x10\x55\xd5\x4f\xe0\x55\xb9\x9b\xe0\x0e\x1d" +
+		"\x07example\x03com\x00\x01\x02\x03\x04")
+	rrsigData = &Rrsig{
+		TypeCovered: MX,
+		Algorithm:   ED25519,
+		Labels:      2,
+		OriginalTTL: 3600,
+		Expiration:  1440042976,
+		Inception:   1438227424,
+		KeyTag:      3613,
+		SignerName:  "example.com",
+		Signature:   []byte("\x01\x02\x03\x04"),
+	}
+)
+
+func TestRrsig_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		length  uint16
+		want    *Rrsig
+		wantErr bool
+	}{
+		{
+			name:   "Simple RRSIG",
+			buf:    rrsigWire,
+			length: uint16(len(rrsigWire)),
+			want:   rrsigData,
+		},
+		{
+			name:    "Truncated RRSIG",
+			buf:     rrsigWire[:10],
+			length:  uint16(len(rrsigWire)),
+			wantErr: true,
+		},
+		{
+			name:    "Signer name exceeding RDATA",
+			buf:     rrsigWire,
+			length:  20,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Rrsig{length: tt.length}
+			if err := s.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Rrsig.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			s.length = 0
+			if !reflect.DeepEqual(s, tt.want) {
+				t.Errorf("Rrsig.Parse() = %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestRrsig_Build(t *testing.T) {
+	// The signer name must not be compressed, even if known
+	domains := NewDomains()
+	domains.SetBuild(12, "example.com")
+	buf := new(bytes.Buffer)
+	s := *rrsigData
+	if length, err := s.PreBuild(&Record{}, domains); err != nil || length != len(rrsigWire) {
+		t.Errorf("Rrsig.PreBuild() = %v, %v, want %v", length, err, len(rrsigWire))
+		return
+	}
+	if err := s.Build(buf, domains); err != nil {
+		t.Errorf("Rrsig.Build() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(buf.Bytes(), rrsigWire) {
+		t.Errorf("Rrsig.Build() = %v, want %v", buf.Bytes(), rrsigWire)
+	}
+}
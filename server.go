@@ -0,0 +1,344 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default settings of a Server
+const (
+	DefaultServerAddr  = ":53"
+	DefaultIdleTimeout = 10 * time.Second
+)
+
+// ErrServerClosed is returned by the Serve methods after a call to Close
+var ErrServerClosed = errors.New("dns: server closed")
+
+// Handler responds to a DNS query
+type Handler interface {
+	ServeDNS(w ResponseWriter, r *Message)
+}
+
+// HandlerFunc allows an ordinary function to be used as a Handler
+type HandlerFunc func(w ResponseWriter, r *Message)
+
+// ServeDNS calls f(w, r)
+func (f HandlerFunc) ServeDNS(w ResponseWriter, r *Message) { f(w, r) }
+
+// ResponseWriter is used by a Handler to reply to a query. If a handler writes
+// no message, the query is left unanswered.
+type ResponseWriter interface {
+	// WriteMessage builds and sends m. Responses over UDP that are larger
+	// than the client accepts are truncated with BuildWithLimit.
+	WriteMessage(m *Message) error
+
+	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
+}
+
+// ServeMux dispatches queries to the handler of the closest enclosing zone of
+// the first question. Queries outside of all zones are refused.
+type ServeMux struct {
+	mu    sync.RWMutex
+	zones map[string]Handler
+}
+
+// NewServeMux returns an empty ServeMux
+func NewServeMux() *ServeMux {
+	return &ServeMux{zones: map[string]Handler{}}
+}
+
+// Handle registers h for zone and its subdomains. The root zone "" matches all
+// queries.
+func (mux *ServeMux) Handle(zone string, h Handler) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.zones[CanonicalName(strings.TrimSuffix(zone, "."))] = h
+}
+
+// HandleFunc registers f for zone and its subdomains
+func (mux *ServeMux) HandleFunc(zone string, f func(w ResponseWriter, r *Message)) {
+	mux.Handle(zone, HandlerFunc(f))
+}
+
+// HandleRemove removes the handler of zone
+func (mux *ServeMux) HandleRemove(zone string) {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	delete(mux.zones, CanonicalName(strings.TrimSuffix(zone, ".")))
+}
+
+// Handler returns the handler for name, or nil if no zone encloses it
+func (mux *ServeMux) Handler(name string) Handler {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+	name = CanonicalName(strings.TrimSuffix(name, "."))
+	for {
+		if h, ok := mux.zones[name]; ok {
+			return h
+		}
+		if name == "" {
+			return nil
+		}
+		name = parentName(name)
+	}
+}
+
+// ServeDNS implements Handler, answering FORMERR to queries without a question,
+// BADVERS to queries with an EDNS version other than 0 and REFUSED to queries
+// for names outside of the registered zones
+func (mux *ServeMux) ServeDNS(w ResponseWriter, r *Message) {
+	if len(r.Questions) == 0 {
+		reply := ReplyTo(r)
+		reply.RCode = RCodeFormErr
+		w.WriteMessage(reply)
+		return
+	}
+	if opt := r.Opt(); opt != nil && opt.EDNSVersion > 0 {
+		// The OPT record of the reply holds version 0, the highest supported
+		reply := ReplyTo(r)
+		reply.SetExtendedRCode(RCodeBadVers)
+		w.WriteMessage(reply)
+		return
+	}
+	h := mux.Handler(r.Questions[0].Domain)
+	if h == nil {
+		reply := ReplyTo(r)
+		reply.RCode = RCodeRefused
+		w.WriteMessage(reply)
+		return
+	}
+	h.ServeDNS(w, r)
+}
+
+// Server answers DNS queries over UDP and TCP. Messages that can not be parsed
+// and messages that are not queries are dropped. TCP connections may carry
+// several queries, which are handled concurrently.
+type Server struct {
+	// Addr to listen on, defaults to DefaultServerAddr
+	Addr string
+
+	// Handler for all queries, queries are refused if nil
+	Handler Handler
+
+	// IdleTimeout closes TCP connections waiting for a query for longer,
+	// defaults to DefaultIdleTimeout
+	IdleTimeout time.Duration
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[any]func() error
+	conns     map[net.Conn]struct{}
+}
+
+// ListenAndServe listens on Addr over both UDP and TCP and serves queries
+// until Close is called or either listener fails
+func (s *Server) ListenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = DefaultServerAddr
+	}
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		return err
+	}
+	errs := make(chan error, 2)
+	go func() { errs <- s.ServeUDP(pc) }()
+	go func() { errs <- s.ServeTCP(ln) }()
+	err = <-errs
+	s.Close()
+	<-errs
+	return err
+}
+
+// ServeUDP serves queries received on conn until Close is called
+func (s *Server) ServeUDP(conn net.PacketConn) error {
+	if !s.track(conn, conn.Close) {
+		conn.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(conn)
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		m, err := ParseMessage(bytes.NewBuffer(append([]byte(nil), buf[:n]...)))
+		if err != nil || m.QR {
+			continue
+		}
+		go s.handler().ServeDNS(&udpResponseWriter{conn: conn, addr: addr, query: m}, m)
+	}
+}
+
+// ServeTCP accepts connections on ln and serves their queries until Close is
+// called
+func (s *Server) ServeTCP(ln net.Listener) error {
+	if !s.track(ln, ln.Close) {
+		ln.Close()
+		return ErrServerClosed
+	}
+	defer s.untrack(ln)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	if s.conns == nil {
+		s.conns = map[net.Conn]struct{}{}
+	}
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	w := &tcpResponseWriter{conn: conn}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn.SetReadDeadline(time.Now().Add(s.idleTimeout()))
+		frame, err := readFrame(conn)
+		if err != nil {
+			return
+		}
+		m, err := ParseMessage(bytes.NewBuffer(frame))
+		if err != nil || m.QR {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handler().ServeDNS(w, m)
+		}()
+	}
+}
+
+// Close stops all listeners and closes open TCP connections. Queries that are
+// being handled can still be answered over UDP.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	var errs []error
+	for _, closeFn := range s.listeners {
+		if err := closeFn(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server) track(l any, closeFn func() error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.listeners == nil {
+		s.listeners = map[any]func() error{}
+	}
+	s.listeners[l] = closeFn
+	return true
+}
+
+func (s *Server) untrack(l any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.listeners, l)
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) handler() Handler {
+	if s.Handler != nil {
+		return s.Handler
+	}
+	return NewServeMux()
+}
+
+func (s *Server) idleTimeout() time.Duration {
+	if s.IdleTimeout > 0 {
+		return s.IdleTimeout
+	}
+	return DefaultIdleTimeout
+}
+
+type udpResponseWriter struct {
+	conn  net.PacketConn
+	addr  net.Addr
+	query *Message
+}
+
+func (w *udpResponseWriter) WriteMessage(m *Message) error {
+	buf := new(bytes.Buffer)
+	domains := AcquireDomains()
+	defer domains.Release()
+	if err := m.BuildWithLimit(buf, domains, w.query.UDPSize()); err != nil {
+		return err
+	}
+	_, err := w.conn.WriteTo(buf.Bytes(), w.addr)
+	return err
+}
+
+func (w *udpResponseWriter) LocalAddr() net.Addr  { return w.conn.LocalAddr() }
+func (w *udpResponseWriter) RemoteAddr() net.Addr { return w.addr }
+
+type tcpResponseWriter struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+func (w *tcpResponseWriter) WriteMessage(m *Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return WriteMessage(w.conn, m)
+}
+
+func (w *tcpResponseWriter) LocalAddr() net.Addr  { return w.conn.LocalAddr() }
+func (w *tcpResponseWriter) RemoteAddr() net.Addr { return w.conn.RemoteAddr() }
@@ -0,0 +1,205 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+type testResponseWriter struct {
+	msgs []*Message
+}
+
+func (w *testResponseWriter) WriteMessage(m *Message) error {
+	w.msgs = append(w.msgs, m)
+	return nil
+}
+
+func (*testResponseWriter) LocalAddr() net.Addr  { return nil }
+func (*testResponseWriter) RemoteAddr() net.Addr { return nil }
+
+func TestServeMux(t *testing.T) {
+	mux := NewServeMux()
+	for _, zone := range []string{"example.com", "sub.example.com.", "Example.ORG"} {
+		mux.HandleFunc(zone, func(w ResponseWriter, r *Message) {
+			reply := testAnswer(r, "192.0.2.1")
+			reply.Answers[0].Name = zone
+			w.WriteMessage(reply)
+		})
+	}
+	tests := []struct {
+		name      string
+		domain    string
+		wantZone  string
+		wantRCode uint8
+	}{
+		{name: "Zone apex", domain: "example.com", wantZone: "example.com"},
+		{name: "Subdomain", domain: "www.example.com", wantZone: "example.com"},
+		{name: "Closest zone", domain: "www.sub.example.com", wantZone: "sub.example.com."},
+		{name: "Case insensitive", domain: "WWW.example.org", wantZone: "Example.ORG"},
+		{name: "Label boundary", domain: "notexample.com", wantRCode: RCodeRefused},
+		{name: "Outside zones", domain: "example.net", wantRCode: RCodeRefused},
+		{name: "No question", wantRCode: RCodeFormErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQuery()
+			q.Questions[0].Domain = tt.domain
+			if tt.domain == "" {
+				q.Questions = nil
+			}
+			w := &testResponseWriter{}
+			mux.ServeDNS(w, q)
+			if len(w.msgs) != 1 {
+				t.Fatalf("ServeMux.ServeDNS() wrote %d messages, want 1", len(w.msgs))
+			}
+			got := w.msgs[0]
+			if got.RCode != tt.wantRCode {
+				t.Errorf("ServeMux.ServeDNS() rcode = %d, want %d", got.RCode, tt.wantRCode)
+			}
+			if tt.wantZone != "" && got.Answers[0].Name != tt.wantZone {
+				t.Errorf("ServeMux.ServeDNS() handled by %s, want %s", got.Answers[0].Name, tt.wantZone)
+			}
+		})
+	}
+
+	mux.HandleRemove("sub.example.com")
+	if h := mux.Handler("www.sub.example.com"); h == nil {
+		t.Error("ServeMux.Handler() after HandleRemove = nil, want parent zone handler")
+	}
+	mux.Handle("", HandlerFunc(func(ResponseWriter, *Message) {}))
+	if h := mux.Handler("example.net"); h == nil {
+		t.Error("ServeMux.Handler() with root zone = nil, want handler")
+	}
+}
+
+// testServer serves h over UDP and TCP on a shared local port
+func testServer(t *testing.T, h Handler) (*Server, string) {
+	t.Helper()
+	s := &Server{Handler: h}
+	for i := 0; i < 10; i++ {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln, err := net.Listen("tcp", pc.LocalAddr().String())
+		if err != nil {
+			pc.Close()
+			continue
+		}
+		go s.ServeUDP(pc)
+		go s.ServeTCP(ln)
+		t.Cleanup(func() { s.Close() })
+		return s, pc.LocalAddr().String()
+	}
+	t.Fatal("unable to listen on a shared UDP and TCP port")
+	return nil, ""
+}
+
+func TestServer(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("example.com", func(w ResponseWriter, r *Message) {
+		w.WriteMessage(testAnswer(r, "192.0.2.1"))
+	})
+	mux.HandleFunc("large.example", func(w ResponseWriter, r *Message) {
+		reply := ReplyTo(r)
+		for i := 0; i < 40; i++ {
+			reply.Answers = append(reply.Answers, testAnswer(r, "192.0.2.2").Answers...)
+		}
+		w.WriteMessage(reply)
+	})
+	_, addr := testServer(t, mux)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c := &Client{Timeout: time.Second}
+
+	resp, err := c.Exchange(ctx, testQuery(), addr)
+	if err != nil {
+		t.Fatalf("UDP query error = %v", err)
+	}
+	if len(resp.Answers) != 1 {
+		t.Errorf("UDP query answers = %d, want 1", len(resp.Answers))
+	}
+
+	q := testQuery()
+	q.Questions[0].Domain = "www.example.net"
+	resp, err = c.Exchange(ctx, q, addr)
+	if err != nil {
+		t.Fatalf("Refused query error = %v", err)
+	}
+	if resp.RCode != RCodeRefused {
+		t.Errorf("Refused query rcode = %d, want %d", resp.RCode, RCodeRefused)
+	}
+
+	// The truncated UDP response makes the client retry over TCP
+	q = testQuery()
+	q.Questions[0].Domain = "large.example"
+	resp, err = c.Exchange(ctx, q, addr)
+	if err != nil {
+		t.Fatalf("Large query error = %v", err)
+	}
+	if resp.TC || len(resp.Answers) != 40 {
+		t.Errorf("Large query = %d answers, TC %v, want 40 answers over TCP",
+			len(resp.Answers), resp.TC)
+	}
+
+	// Pipelined queries on a single TCP connection
+	tcp, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := NewConn(tcp)
+	defer conn.Close()
+	for _, domain := range []string{"a.example.com", "b.example.com"} {
+		q := testQuery()
+		q.Questions[0].Domain = domain
+		resp, err := conn.Exchange(ctx, q)
+		if err != nil {
+			t.Fatalf("TCP query error = %v", err)
+		}
+		if resp.Answers[0].Name != domain {
+			t.Errorf("TCP query answer = %s, want %s", resp.Answers[0].Name, domain)
+		}
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	s := &Server{Addr: "127.0.0.1:0"}
+	errs := make(chan error, 1)
+	go func() { errs <- s.ListenAndServe() }()
+	time.Sleep(50 * time.Millisecond)
+	s.Close()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Server.ListenAndServe() error = %v, want %v", err, ErrServerClosed)
+		}
+	case <-time.After(time.Second):
+		t.Error("Server.ListenAndServe() did not return after Close")
+	}
+}
+
+func TestServeMux_BadVers(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("example.com", func(w ResponseWriter, r *Message) {
+		w.WriteMessage(testAnswer(r, "192.0.2.1"))
+	})
+	q := testQuery()
+	q.Additional = []Record{*DefaultOpt(1232)}
+	q.Opt().EDNSVersion = 1
+	w := &testResponseWriter{}
+	mux.ServeDNS(w, q)
+	if len(w.msgs) != 1 {
+		t.Fatalf("ServeMux.ServeDNS() wrote %d messages, want 1", len(w.msgs))
+	}
+	got := w.msgs[0]
+	if got.ExtendedRCode() != RCodeBadVers || got.Opt().EDNSVersion != 0 {
+		t.Errorf("ServeMux.ServeDNS() = %s with EDNS version %d, want BADVERS with version 0",
+			rCodeString(got.ExtendedRCode()), got.Opt().EDNSVersion)
+	}
+	if len(got.Answers) != 0 {
+		t.Errorf("ServeMux.ServeDNS() answers = %d, want 0", len(got.Answers))
+	}
+}
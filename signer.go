@@ -0,0 +1,470 @@
+package dns
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default signature validity used when the Signer has no explicit period
+const (
+	DefaultSignatureInception  = time.Hour
+	DefaultSignatureExpiration = 30 * 24 * time.Hour
+)
+
+// SigningKey is a DNSKEY together with its private key
+type SigningKey struct {
+	Key     *Dnskey
+	Private crypto.Signer
+}
+
+// GenerateKey generates a new key pair for the DNSSEC algorithm alg. RSA keys
+// are 2048 bits.
+func GenerateKey(alg uint8, flags uint16) (*SigningKey, error) {
+	var priv crypto.Signer
+	var err error
+	switch alg {
+	case RSASHA256, RSASHA512:
+		priv, err = rsa.GenerateKey(rand.Reader, 2048)
+	case ECDSAP256SHA256:
+		priv, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case ECDSAP384SHA384:
+		priv, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case ED25519:
+		_, priv, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, alg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewDnskey(flags, alg, priv.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{Key: key, Private: priv}, nil
+}
+
+// NewDnskey creates a DNSKEY holding the public key pub for algorithm alg
+func NewDnskey(flags uint16, alg uint8, pub crypto.PublicKey) (*Dnskey, error) {
+	key := &Dnskey{Flags: flags, Protocol: DnskeyProtocol, Algorithm: alg}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if alg != RSASHA1 && alg != RSASHA1NSEC3SHA1 && alg != RSASHA256 && alg != RSASHA512 {
+			return nil, fmt.Errorf("RSA key used with algorithm %d", alg)
+		}
+		// Exponent length encoding from RFC 3110 section 2
+		exp := big.NewInt(int64(pub.E)).Bytes()
+		if len(exp) > 255 {
+			key.PublicKey = []byte{0, byte(len(exp) >> 8), byte(len(exp))}
+		} else {
+			key.PublicKey = []byte{byte(len(exp))}
+		}
+		key.PublicKey = append(key.PublicKey, exp...)
+		key.PublicKey = append(key.PublicKey, pub.N.Bytes()...)
+	case *ecdsa.PublicKey:
+		if (alg == ECDSAP256SHA256 && pub.Curve != elliptic.P256()) ||
+			(alg == ECDSAP384SHA384 && pub.Curve != elliptic.P384()) ||
+			(alg != ECDSAP256SHA256 && alg != ECDSAP384SHA384) {
+			return nil, fmt.Errorf("ECDSA key does not match algorithm %d", alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		key.PublicKey = append(pub.X.FillBytes(make([]byte, size)),
+			pub.Y.FillBytes(make([]byte, size))...)
+	case ed25519.PublicKey:
+		if alg != ED25519 {
+			return nil, fmt.Errorf("Ed25519 key used with algorithm %d", alg)
+		}
+		key.PublicKey = append([]byte(nil), pub...)
+	default:
+		return nil, fmt.Errorf("%w: public key type %T", ErrUnsupportedAlgorithm, pub)
+	}
+	return key, nil
+}
+
+// Signer signs RRsets and zones with DNSSEC. Key signing keys sign the DNSKEY
+// RRset while zone signing keys sign everything else. If either set is empty
+// the other is used for both, which makes a combined signing key setup.
+type Signer struct {
+	Zone string
+	KSKs []*SigningKey
+	ZSKs []*SigningKey
+
+	// Inception and Expiration of the signatures, when unset they default to
+	// DefaultSignatureInception before and DefaultSignatureExpiration after
+	// the time of signing
+	Inception  time.Time
+	Expiration time.Time
+
+	// NSEC3 selects an NSEC3 chain with the given parameters instead of an
+	// NSEC chain when signing a zone. Setting the Opt-Out flag leaves
+	// insecure delegations out of the chain.
+	NSEC3 *Nsec3Param
+}
+
+// SignRRset signs rrset with the keys of the signer, returning one RRSIG per
+// key. It is suited for online signing of synthesized answers.
+func (s *Signer) SignRRset(rrset []Record) ([]Record, error) {
+	if len(rrset) == 0 {
+		return nil, errors.New("dnssec: empty RRset")
+	}
+	if !IsSubdomain(rrset[0].Name, s.Zone) {
+		return nil, fmt.Errorf("dnssec: %s is not in zone %s", rrset[0].Name, s.Zone)
+	}
+	keys := s.ZSKs
+	if (rrset[0].Type == DNSKEY && len(s.KSKs) > 0) || len(keys) == 0 {
+		keys = s.KSKs
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("dnssec: no signing keys")
+	}
+
+	inception, expiration := s.Inception, s.Expiration
+	if inception.IsZero() {
+		inception = time.Now().Add(-DefaultSignatureInception)
+	}
+	if expiration.IsZero() {
+		expiration = time.Now().Add(DefaultSignatureExpiration)
+	}
+
+	sigs := make([]Record, 0, len(keys))
+	for _, key := range keys {
+		sig := &Rrsig{
+			TypeCovered: rrset[0].Type,
+			Algorithm:   key.Key.Algorithm,
+			Labels:      rrsigLabels(rrset[0].Name),
+			OriginalTTL: rrset[0].TTL,
+			Expiration:  uint32(expiration.Unix()),
+			Inception:   uint32(inception.Unix()),
+			KeyTag:      key.Key.KeyTag(),
+			SignerName:  CanonicalName(s.Zone),
+		}
+		data, err := signedData(sig, rrset)
+		if err != nil {
+			return nil, err
+		}
+		if sig.Signature, err = signData(key, data); err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, Record{
+			Name:  rrset[0].Name,
+			Type:  RRSIG,
+			Class: rrset[0].Class,
+			TTL:   rrset[0].TTL,
+			Data:  sig,
+		})
+	}
+	return sigs, nil
+}
+
+// signData signs data with key, returning the signature in the format of
+// RFC 3110, RFC 6605 or RFC 8080 for the algorithm of the key
+func signData(key *SigningKey, data []byte) ([]byte, error) {
+	if key.Key.Algorithm == ED25519 {
+		return key.Private.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	h, hashed := algorithmHash(key.Key.Algorithm, data)
+	sig, err := key.Private.Sign(rand.Reader, hashed, h)
+	if err != nil {
+		return nil, err
+	}
+	if key.Key.Algorithm != ECDSAP256SHA256 && key.Key.Algorithm != ECDSAP384SHA384 {
+		return sig, nil
+	}
+	// crypto.Signer returns ASN.1 encoded ECDSA signatures, DNSSEC uses the
+	// fixed size concatenation of r and s
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &rs); err != nil {
+		return nil, fmt.Errorf("dnssec: invalid ECDSA signature: %w", err)
+	}
+	size := 32
+	if key.Key.Algorithm == ECDSAP384SHA384 {
+		size = 48
+	}
+	return append(rs.R.FillBytes(make([]byte, size)), rs.S.FillBytes(make([]byte, size))...), nil
+}
+
+// DSRecords returns DS records for the key signing keys of the signer, to be
+// published in the parent zone
+func (s *Signer) DSRecords(digestType uint8, ttl uint32) ([]Record, error) {
+	return s.dsRecords(DS, digestType, ttl)
+}
+
+// CDSRecords returns CDS records for the key signing keys of the signer, to be
+// published in the zone itself as described in RFC 7344
+func (s *Signer) CDSRecords(digestType uint8, ttl uint32) ([]Record, error) {
+	return s.dsRecords(CDS, digestType, ttl)
+}
+
+func (s *Signer) dsRecords(t Type, digestType uint8, ttl uint32) ([]Record, error) {
+	keys := s.KSKs
+	if len(keys) == 0 {
+		keys = s.ZSKs
+	}
+	records := make([]Record, 0, len(keys))
+	for _, key := range keys {
+		ds, err := NewDs(s.Zone, key.Key, digestType)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, Record{
+			Name:  s.Zone,
+			Type:  t,
+			Class: uint16(IN),
+			TTL:   ttl,
+			Data:  ds,
+		})
+	}
+	return records, nil
+}
+
+// rrsetKey identifies an RRset in a zone
+type rrsetKey struct {
+	name  string
+	t     Type
+	class uint16
+}
+
+// SignZone signs the records of a zone. Existing signatures and NSEC/NSEC3
+// records are removed, DNSKEY records for all keys are added at the apex and
+// an NSEC or NSEC3 chain is generated before every authoritative RRset is
+// signed. The returned records are in canonical order, with each RRset
+// followed by its signatures.
+func (s *Signer) SignZone(records []Record) ([]Record, error) {
+	apex := CanonicalName(s.Zone)
+	rrsets := map[rrsetKey][]Record{}
+	var soa *Record
+	for i := range records {
+		r := records[i]
+		switch r.Type {
+		case RRSIG, NSEC, NSEC3, NSEC3PARAM:
+			continue
+		}
+		if !IsSubdomain(r.Name, apex) {
+			return nil, fmt.Errorf("dnssec: %s is not in zone %s", r.Name, s.Zone)
+		}
+		if r.Type == SOA && CanonicalName(r.Name) == apex {
+			soa = &records[i]
+		}
+		addToRRset(rrsets, r)
+	}
+	if soa == nil {
+		return nil, fmt.Errorf("dnssec: zone %s has no SOA", s.Zone)
+	}
+	soaData, ok := soa.Data.(*Soa)
+	if !ok {
+		return nil, fmt.Errorf("dnssec: zone %s SOA has unsupported RDATA %T", s.Zone, soa.Data)
+	}
+	negativeTTL := soa.TTL
+	if soaData.Minimum < negativeTTL {
+		negativeTTL = soaData.Minimum
+	}
+
+	keyTTL := soa.TTL
+	if existing := rrsets[rrsetKey{apex, DNSKEY, soa.Class}]; len(existing) > 0 {
+		keyTTL = existing[0].TTL
+	}
+	for _, key := range append(append([]*SigningKey{}, s.KSKs...), s.ZSKs...) {
+		addToRRset(rrsets, Record{Name: s.Zone, Type: DNSKEY, Class: soa.Class,
+			TTL: keyTTL, Data: key.Key})
+	}
+	if s.NSEC3 != nil {
+		param := &Nsec3Param{
+			HashAlgorithm: s.NSEC3.HashAlgorithm,
+			Iterations:    s.NSEC3.Iterations,
+			Salt:          s.NSEC3.Salt,
+		}
+		addToRRset(rrsets, Record{Name: s.Zone, Type: NSEC3PARAM, Class: soa.Class,
+			TTL: negativeTTL, Data: param})
+	}
+
+	// Collect the types at each name, and find delegation points
+	types := map[string][]Type{}
+	delegations := map[string]bool{}
+	for key := range rrsets {
+		types[key.name] = append(types[key.name], key.t)
+		if key.t == NS && key.name != apex {
+			delegations[key.name] = true
+		}
+	}
+	var names []string
+	for name := range types {
+		if !belowDelegation(name, apex, delegations) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return CompareNames(names[i], names[j]) < 0 })
+
+	var chain []Record
+	var err error
+	if s.NSEC3 != nil {
+		chain, err = s.nsec3Chain(apex, names, types, delegations, soa.Class, negativeTTL)
+	} else {
+		chain = s.nsecChain(names, types, delegations, soa.Class, negativeTTL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range chain {
+		addToRRset(rrsets, r)
+	}
+
+	keys := make([]rrsetKey, 0, len(rrsets))
+	for key := range rrsets {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := CompareNames(keys[i].name, keys[j].name); c != 0 {
+			return c < 0
+		}
+		return keys[i].t < keys[j].t
+	})
+
+	var signed []Record
+	for _, key := range keys {
+		rrset := rrsets[key]
+		signed = append(signed, rrset...)
+		if belowDelegation(key.name, apex, delegations) ||
+			(delegations[key.name] && key.t != DS && key.t != NSEC) {
+			// Glue and delegation NS records are not authoritative
+			continue
+		}
+		sigs, err := s.SignRRset(rrset)
+		if err != nil {
+			return nil, err
+		}
+		signed = append(signed, sigs...)
+	}
+	return signed, nil
+}
+
+// addToRRset adds r to its RRset unless the RRset holds identical RDATA
+func addToRRset(rrsets map[rrsetKey][]Record, r Record) {
+	key := rrsetKey{CanonicalName(r.Name), r.Type, r.Class}
+	rdata, _ := canonicalRData(&r)
+	for _, other := range rrsets[key] {
+		if existing, _ := canonicalRData(&other); bytes.Equal(existing, rdata) {
+			return
+		}
+	}
+	rrsets[key] = append(rrsets[key], r)
+}
+
+// belowDelegation reports whether name is strictly below a delegation point
+func belowDelegation(name, apex string, delegations map[string]bool) bool {
+	for parent := name; parent != apex && parent != ""; {
+		parent = parentName(parent)
+		if delegations[parent] {
+			return true
+		}
+	}
+	return false
+}
+
+// chainTypes returns the types of the NSEC or NSEC3 record at a name
+func chainTypes(name string, types map[string][]Type, delegations map[string]bool,
+	chainType Type,
+) []Type {
+	out := append([]Type{}, types[name]...)
+	if chainType == NSEC {
+		out = append(out, NSEC)
+	}
+	// Insecure delegations have no signatures
+	if len(out) > 0 && (!delegations[name] || hasType(out, DS) || chainType == NSEC) {
+		out = append(out, RRSIG)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// nsecChain creates the NSEC chain of RFC 4035 section 2.3 over the
+// authoritative names of the zone
+func (s *Signer) nsecChain(names []string, types map[string][]Type,
+	delegations map[string]bool, class uint16, ttl uint32,
+) []Record {
+	chain := make([]Record, 0, len(names))
+	for i, name := range names {
+		chain = append(chain, Record{
+			Name:  name,
+			Type:  NSEC,
+			Class: class,
+			TTL:   ttl,
+			Data: &Nsec{
+				NextDomain: names[(i+1)%len(names)],
+				TypeBitMap: chainTypes(name, types, delegations, NSEC),
+			},
+		})
+	}
+	return chain
+}
+
+// nsec3Chain creates the NSEC3 chain of RFC 5155 section 7.1, including empty
+// non-terminals and leaving out insecure delegations when Opt-Out is set
+func (s *Signer) nsec3Chain(apex string, names []string, types map[string][]Type,
+	delegations map[string]bool, class uint16, ttl uint32,
+) ([]Record, error) {
+	optOut := s.NSEC3.Flags&Nsec3OptOut == Nsec3OptOut
+	all := map[string]bool{}
+	for _, name := range names {
+		if optOut && delegations[name] && !hasType(types[name], DS) {
+			continue
+		}
+		all[name] = true
+		// Empty non-terminals between the name and the apex
+		labels := nameLabels(name)
+		for i := 1; i < len(labels)-len(nameLabels(apex)); i++ {
+			all[strings.Join(labels[i:], ".")] = true
+		}
+	}
+
+	type hashed struct {
+		name string
+		hash []byte
+	}
+	entries := make([]hashed, 0, len(all))
+	for name := range all {
+		hash, err := HashName(name, s.NSEC3.HashAlgorithm, s.NSEC3.Iterations, s.NSEC3.Salt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, hashed{name: name, hash: hash})
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].hash, entries[j].hash) < 0 })
+
+	chain := make([]Record, 0, len(entries))
+	for i, e := range entries {
+		if i > 0 && bytes.Equal(e.hash, entries[i-1].hash) {
+			return nil, fmt.Errorf("dnssec: NSEC3 hash collision for %s", e.name)
+		}
+		owner := strings.ToLower(nsec3Encoding.EncodeToString(e.hash))
+		if apex != "" {
+			owner += "." + apex
+		}
+		chain = append(chain, Record{
+			Name:  owner,
+			Type:  NSEC3,
+			Class: class,
+			TTL:   ttl,
+			Data: &Nsec3{
+				HashAlgorithm: s.NSEC3.HashAlgorithm,
+				Flags:         s.NSEC3.Flags & Nsec3OptOut,
+				Iterations:    s.NSEC3.Iterations,
+				Salt:          s.NSEC3.Salt,
+				NextHashed:    entries[(i+1)%len(entries)].hash,
+				TypeBitMap:    chainTypes(e.name, types, delegations, NSEC3),
+			},
+		})
+	}
+	return chain, nil
+}
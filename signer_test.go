@@ -0,0 +1,306 @@
+package dns
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewDnskey(t *testing.T) {
+	for _, alg := range []uint8{RSASHA256, ECDSAP256SHA256, ECDSAP384SHA384, ED25519} {
+		t.Run(AlgorithmStrings[alg], func(t *testing.T) {
+			key, err := GenerateKey(alg, DnskeyZoneKey)
+			if err != nil {
+				t.Fatalf("GenerateKey() error = %v", err)
+			}
+			pub, err := key.Key.CryptoPublicKey()
+			if err != nil {
+				t.Fatalf("Dnskey.CryptoPublicKey() error = %v", err)
+			}
+			if !reflect.DeepEqual(pub, key.Private.Public()) {
+				t.Errorf("Dnskey.CryptoPublicKey() = %v, want %v", pub, key.Private.Public())
+			}
+		})
+	}
+
+	t.Run("Mismatched algorithm", func(t *testing.T) {
+		priv, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if _, err := NewDnskey(DnskeyZoneKey, ECDSAP384SHA384, priv.Public()); err == nil {
+			t.Errorf("NewDnskey() error = nil, want error")
+		}
+		if _, err := NewDnskey(DnskeyZoneKey, ED25519, priv.Public()); err == nil {
+			t.Errorf("NewDnskey() error = nil, want error")
+		}
+	})
+
+	t.Run("Unsupported algorithm", func(t *testing.T) {
+		if _, err := GenerateKey(ECCGOST, DnskeyZoneKey); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("GenerateKey() error = %v, want %v", err, ErrUnsupportedAlgorithm)
+		}
+	})
+}
+
+func testSigner(t *testing.T, alg uint8) *Signer {
+	t.Helper()
+	ksk, err := GenerateKey(alg, DnskeyZoneKey|DnskeySEP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zsk, err := GenerateKey(alg, DnskeyZoneKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{
+		Zone:       "example",
+		KSKs:       []*SigningKey{ksk},
+		ZSKs:       []*SigningKey{zsk},
+		Inception:  testNow.Add(-time.Hour),
+		Expiration: testNow.Add(time.Hour),
+	}
+}
+
+func TestSigner_SignRRset(t *testing.T) {
+	for _, alg := range []uint8{RSASHA256, ECDSAP256SHA256, ECDSAP384SHA384, ED25519} {
+		t.Run(AlgorithmStrings[alg], func(t *testing.T) {
+			s := testSigner(t, alg)
+			rrset := testARRset("www.example", "192.0.2.1", "192.0.2.2")
+			sigs, err := s.SignRRset(rrset)
+			if err != nil {
+				t.Fatalf("Signer.SignRRset() error = %v", err)
+			}
+			if len(sigs) != 1 {
+				t.Fatalf("Signer.SignRRset() returned %d signatures, want 1", len(sigs))
+			}
+			sig := sigs[0].Data.(*Rrsig)
+			if sig.KeyTag != s.ZSKs[0].Key.KeyTag() {
+				t.Errorf("Rrsig.KeyTag = %d, want ZSK %d", sig.KeyTag, s.ZSKs[0].Key.KeyTag())
+			}
+			if err := sig.Verify(s.ZSKs[0].Key, rrset); err != nil {
+				t.Errorf("Rrsig.Verify() error = %v", err)
+			}
+		})
+	}
+
+	s := testSigner(t, ED25519)
+	t.Run("DNSKEY signed by KSK", func(t *testing.T) {
+		keys := []Record{{Name: "example", Type: DNSKEY, Class: uint16(IN), TTL: 3600, Data: s.KSKs[0].Key}}
+		sigs, err := s.SignRRset(keys)
+		if err != nil {
+			t.Fatalf("Signer.SignRRset() error = %v", err)
+		}
+		if got := sigs[0].Data.(*Rrsig).KeyTag; got != s.KSKs[0].Key.KeyTag() {
+			t.Errorf("Rrsig.KeyTag = %d, want KSK %d", got, s.KSKs[0].Key.KeyTag())
+		}
+	})
+
+	t.Run("Out of zone", func(t *testing.T) {
+		if _, err := s.SignRRset(testARRset("www.example.org", "192.0.2.1")); err == nil {
+			t.Errorf("Signer.SignRRset() error = nil, want error")
+		}
+	})
+}
+
+func TestSigner_DSRecords(t *testing.T) {
+	s := testSigner(t, ECDSAP256SHA256)
+	for _, build := range []func(uint8, uint32) ([]Record, error){s.DSRecords, s.CDSRecords} {
+		records, err := build(DigestSHA256, 3600)
+		if err != nil {
+			t.Fatalf("Signer.DSRecords() error = %v", err)
+		}
+		if len(records) != 1 || !records[0].Data.(*Ds).Matches("example", s.KSKs[0].Key) {
+			t.Errorf("Signer.DSRecords() = %v, want DS for KSK", records)
+		}
+	}
+}
+
+// testZone is a zone with a secure delegation, an insecure delegation with
+// glue and an empty non-terminal
+func testZone() []Record {
+	rr := func(name string, t Type, data RData) Record {
+		return Record{Name: name, Type: t, Class: uint16(IN), TTL: 3600, Data: data}
+	}
+	return []Record{
+		rr("example", SOA, &Soa{MName: "ns.example", RName: "hostmaster.example",
+			Serial: 1, Refresh: 7200, Retry: 3600, Expire: 1209600, Minimum: 300}),
+		rr("example", NS, &Ns{Name: "ns.example"}),
+		rr("ns.example", A, &IPv4{netip.MustParseAddr("192.0.2.53")}),
+		rr("www.example", A, &IPv4{netip.MustParseAddr("192.0.2.1")}),
+		rr("a.b.example", A, &IPv4{netip.MustParseAddr("192.0.2.2")}),
+		rr("insecure.example", NS, &Ns{Name: "ns.insecure.example"}),
+		rr("ns.insecure.example", A, &IPv4{netip.MustParseAddr("192.0.2.54")}),
+		rr("secure.example", NS, &Ns{Name: "ns.example"}),
+		rr("secure.example", DS, &Ds{KeyTag: 1, Algorithm: ED25519,
+			DigestType: DigestSHA256, Digest: make([]byte, 32)}),
+	}
+}
+
+// splitSigned groups signed zone records into RRsets and their signatures
+func splitSigned(records []Record) (map[rrsetKey][]Record, map[rrsetKey][]Record) {
+	rrsets := map[rrsetKey][]Record{}
+	sigs := map[rrsetKey][]Record{}
+	for _, r := range records {
+		if sig, ok := r.Data.(*Rrsig); ok {
+			key := rrsetKey{r.Name, sig.TypeCovered, r.Class}
+			sigs[key] = append(sigs[key], r)
+			continue
+		}
+		key := rrsetKey{r.Name, r.Type, r.Class}
+		rrsets[key] = append(rrsets[key], r)
+	}
+	return rrsets, sigs
+}
+
+func checkSignedZone(t *testing.T, s *Signer, records []Record) map[rrsetKey][]Record {
+	t.Helper()
+	rrsets, sigs := splitSigned(records)
+
+	anchors, err := s.DSRecords(DigestSHA256, 3600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewValidator()
+	v.Now = func() time.Time { return testNow }
+	v.AddTrustAnchor(anchors[0])
+	keys := rrsetKey{"example", DNSKEY, uint16(IN)}
+	if err := v.Verify(rrsets[keys], sigs[keys]); err != nil {
+		t.Fatalf("Validator.Verify() DNSKEY error = %v", err)
+	}
+
+	unsigned := map[rrsetKey]bool{
+		{"insecure.example", NS, uint16(IN)}:   true,
+		{"ns.insecure.example", A, uint16(IN)}: true,
+		{"secure.example", NS, uint16(IN)}:     true,
+		{"example", DNSKEY, uint16(IN)}:        false,
+		{"www.example", A, uint16(IN)}:         false,
+		{"secure.example", DS, uint16(IN)}:     false,
+		{"a.b.example", A, uint16(IN)}:         false,
+		{"example", SOA, uint16(IN)}:           false,
+		{"ns.example", A, uint16(IN)}:          false,
+	}
+	for key, rrset := range rrsets {
+		if unsigned[key] {
+			if len(sigs[key]) != 0 {
+				t.Errorf("%s %s is signed, want unsigned", key.name, RRTypeStrings[key.t])
+			}
+			continue
+		}
+		if err := v.Verify(rrset, sigs[key]); err != nil {
+			t.Errorf("Validator.Verify() %s %s error = %v", key.name, RRTypeStrings[key.t], err)
+		}
+	}
+	return rrsets
+}
+
+func TestSigner_SignZone(t *testing.T) {
+	t.Run("NSEC", func(t *testing.T) {
+		s := testSigner(t, ED25519)
+		signed, err := s.SignZone(testZone())
+		if err != nil {
+			t.Fatalf("Signer.SignZone() error = %v", err)
+		}
+		rrsets := checkSignedZone(t, s, signed)
+
+		var nsecs []Record
+		for _, r := range signed {
+			if r.Type == NSEC {
+				nsecs = append(nsecs, r)
+				if r.TTL != 300 {
+					t.Errorf("NSEC TTL = %d, want 300", r.TTL)
+				}
+			}
+		}
+		wantOwners := []string{"example", "a.b.example", "insecure.example",
+			"ns.example", "secure.example", "www.example"}
+		var owners []string
+		for _, r := range nsecs {
+			owners = append(owners, r.Name)
+		}
+		if !reflect.DeepEqual(owners, wantOwners) {
+			t.Errorf("NSEC owners = %v, want %v", owners, wantOwners)
+		}
+		wantTypes := []Type{NS, RRSIG, NSEC}
+		if got := rrsets[rrsetKey{"insecure.example", NSEC, uint16(IN)}][0].Data.(*Nsec).TypeBitMap; !reflect.DeepEqual(got, wantTypes) {
+			t.Errorf("Nsec.TypeBitMap = %v, want %v", got, wantTypes)
+		}
+
+		if err := VerifyNSECNameError("nope.example", nsecs); err != nil {
+			t.Errorf("VerifyNSECNameError() error = %v", err)
+		}
+		if err := VerifyNSECNoData("www.example", MX, nsecs); err != nil {
+			t.Errorf("VerifyNSECNoData() error = %v", err)
+		}
+		if err := VerifyNSECNoData("insecure.example", DS, nsecs); err != nil {
+			t.Errorf("VerifyNSECNoData() DS error = %v", err)
+		}
+	})
+
+	t.Run("NSEC3 opt-out", func(t *testing.T) {
+		s := testSigner(t, ECDSAP256SHA256)
+		s.NSEC3 = &Nsec3Param{HashAlgorithm: Nsec3SHA1, Flags: Nsec3OptOut,
+			Iterations: 0, Salt: []byte{0xaa, 0xbb}}
+		signed, err := s.SignZone(testZone())
+		if err != nil {
+			t.Fatalf("Signer.SignZone() error = %v", err)
+		}
+		rrsets := checkSignedZone(t, s, signed)
+		if len(rrsets[rrsetKey{"example", NSEC3PARAM, uint16(IN)}]) != 1 {
+			t.Errorf("Signer.SignZone() did not add NSEC3PARAM")
+		}
+
+		var nsec3s []Record
+		hashes := map[string]bool{}
+		for _, r := range signed {
+			if r.Type == NSEC3 {
+				nsec3s = append(nsec3s, r)
+				hashes[r.Name] = true
+			}
+		}
+		// example, a.b, b (empty non-terminal), ns, secure and www
+		if len(nsec3s) != 6 {
+			t.Errorf("Signer.SignZone() created %d NSEC3 records, want 6", len(nsec3s))
+		}
+		owner, _ := HashedOwner("insecure.example", "example", Nsec3SHA1, 0, s.NSEC3.Salt)
+		if hashes[owner] {
+			t.Errorf("insecure delegation has an NSEC3 record with Opt-Out")
+		}
+
+		if err := VerifyNSEC3NameError("nope.example", nsec3s); err != nil {
+			t.Errorf("VerifyNSEC3NameError() error = %v", err)
+		}
+		if err := VerifyNSEC3NoData("b.example", A, nsec3s); err != nil {
+			t.Errorf("VerifyNSEC3NoData() empty non-terminal error = %v", err)
+		}
+		if err := VerifyNSEC3NoData("insecure.example", DS, nsec3s); err != nil {
+			t.Errorf("VerifyNSEC3NoData() opt-out DS error = %v", err)
+		}
+	})
+
+	t.Run("Missing SOA", func(t *testing.T) {
+		s := testSigner(t, ED25519)
+		if _, err := s.SignZone(testZone()[1:]); err == nil {
+			t.Errorf("Signer.SignZone() error = nil, want error")
+		}
+	})
+
+	t.Run("SOA without parsed RDATA", func(t *testing.T) {
+		s := testSigner(t, ED25519)
+		zone := testZone()
+		zone[0].Data = &Unknown{Data: []byte{0xab}}
+		if _, err := s.SignZone(zone); err == nil {
+			t.Errorf("Signer.SignZone() error = nil, want error")
+		}
+	})
+
+	t.Run("Out of zone record", func(t *testing.T) {
+		s := testSigner(t, ED25519)
+		zone := append(testZone(), testARRset("www.example.org", "192.0.2.1")...)
+		if _, err := s.SignZone(zone); err == nil {
+			t.Errorf("Signer.SignZone() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// serialHalf is 2^(SERIAL_BITS - 1) from RFC 1982
+const serialHalf = 1 << 31
+
+// Soa implements interface RData
+type Soa struct {
+	MName      string
+	RName      string
+	Serial     uint32
+	Refresh    uint32
+	Retry      uint32
+	Expire     uint32
+	Minimum    uint32
+	mnameBytes string
+	rnameBytes string
+}
+
+// Parse implements SOA parsing for interface RData
+func (s *Soa) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
+	bufLen := buf.Len()
+	mname, err := ParseName(buf, ptr, domains)
+	if err != nil {
+		return fmt.Errorf("unable to parse SOA MNAME: %w", err)
+	}
+	rname, err := ParseName(buf, ptr+bufLen-buf.Len(), domains)
+	if err != nil {
+		return fmt.Errorf("unable to parse SOA RNAME: %w", err)
+	}
+	s.MName = mname
+	s.RName = rname
+	for _, field := range []*uint32{
+		&s.Serial, &s.Refresh, &s.Retry, &s.Expire, &s.Minimum,
+	} {
+		if err := binary.Read(buf, binary.BigEndian, field); err != nil {
+			return fmt.Errorf("unable to read SOA timers: %w", err)
+		}
+	}
+	return nil
+}
+
+// Build implements SOA building for interface RData
+func (s *Soa) Build(buf *bytes.Buffer, domains *Domains) error {
+	domains.SetBuild(buf.Len(), s.MName)
+	buf.WriteString(s.mnameBytes)
+	domains.SetBuild(buf.Len(), s.RName)
+	buf.WriteString(s.rnameBytes)
+	for _, field := range []uint32{
+		s.Serial, s.Refresh, s.Retry, s.Expire, s.Minimum,
+	} {
+		if err := binary.Write(buf, binary.BigEndian, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PreBuild implements SOA pre building for interface RData
+func (s *Soa) PreBuild(_ *Record, domains *Domains) (int, error) {
+	var err error
+	if s.mnameBytes, err = buildName(s.MName, domains); err != nil {
+		return 0, err
+	}
+	if s.rnameBytes, err = buildName(s.RName, domains); err != nil {
+		return 0, err
+	}
+	return len(s.mnameBytes) + len(s.rnameBytes) + 20, nil
+}
+
+// TransformName satisfies the interface
+func (*Soa) TransformName(name string) string { return name }
+
+// IncrementSerial increments the serial of the SOA record by one, wrapping
+// around as described in RFC 1982
+func (s *Soa) IncrementSerial() {
+	s.Serial, _ = SerialAdd(s.Serial, 1)
+}
+
+// SerialAdd adds n to serial s using RFC 1982 serial number arithmetic. Adding
+// more than 2^31-1 is undefined and returns an error.
+func SerialAdd(s, n uint32) (uint32, error) {
+	if n > serialHalf-1 {
+		return s, fmt.Errorf("serial addition out of range: %d > %d", n, serialHalf-1)
+	}
+	return s + n, nil
+}
+
+// SerialCompare compares two serial numbers using RFC 1982 serial number
+// arithmetic, returning -1 if s1 < s2, 0 if s1 == s2 and 1 if s1 > s2. The
+// comparison of two serials exactly 2^31 apart is undefined, in which case ok
+// is false.
+func SerialCompare(s1, s2 uint32) (cmp int, ok bool) {
+	switch {
+	case s1 == s2:
+		return 0, true
+	case s1-s2 == serialHalf:
+		return 0, false
+	case SerialLess(s1, s2):
+		return -1, true
+	default:
+		return 1, true
+	}
+}
+
+// SerialLess reports whether s1 is less than s2 using RFC 1982 serial number
+// arithmetic
+func SerialLess(s1, s2 uint32) bool {
+	return (s1 < s2 && s2-s1 < serialHalf) || (s1 > s2 && s1-s2 > serialHalf)
+}
+
+// parseText implements presentation format parsing of SOA records
+func (s *Soa) parseText(t *rdataText) error {
+	var err error
+	if s.MName, err = t.name("MNAME"); err != nil {
+		return err
+	}
+	if s.RName, err = t.name("RNAME"); err != nil {
+		return err
+	}
+	if s.Serial, err = t.uint32("serial"); err != nil {
+		return err
+	}
+	for _, field := range []*uint32{&s.Refresh, &s.Retry, &s.Expire, &s.Minimum} {
+		if *field, err = t.ttl("SOA timer"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the RDATA in presentation format
+func (s *Soa) String() string { return s.format(FormatOptions{}) }
+
+func (s *Soa) format(opts FormatOptions) string {
+	return fmt.Sprintf("%s %s %d %d %d %d %d", opts.name(s.MName), opts.name(s.RName),
+		s.Serial, s.Refresh, s.Retry, s.Expire, s.Minimum)
+}
@@ -0,0 +1,176 @@
+package dns
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSoa_Parse(t *testing.T) {
+	type args struct {
+		buf     []byte
+		ptr     int
+		domains *Domains
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Soa
+		wantErr bool
+	}{
+		{
+			name: "Simple SOA record",
+			args: args{
+				buf: []byte("\x03ns1\x07example\x03com\x00\x0ahostmaster\xc0\x04" +
+					"\x78\x49\x1c\x01\x00\x00\x0e\x10\x00\x00\x07\x08\x00\x12\x75\x00\x00\x00\x0e\x10"),
+				domains: NewDomains(),
+			},
+			want: &Soa{
+				MName:   "ns1.example.com",
+				RName:   "hostmaster.example.com",
+				Serial:  2018057217,
+				Refresh: 3600,
+				Retry:   1800,
+				Expire:  1209600,
+				Minimum: 3600,
+			},
+		},
+		{
+			name: "Missing timers",
+			args: args{
+				buf:     []byte("\x03ns1\x07example\x03com\x00\x0ahostmaster\xc0\x04\x78\x49"),
+				domains: NewDomains(),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Soa{}
+			if err := s.Parse(bytes.NewBuffer(tt.args.buf), tt.args.ptr, tt.args.domains); (err != nil) != tt.wantErr {
+				t.Errorf("Soa.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(s, tt.want) {
+				t.Errorf("Soa.Parse() = %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestSoa_Build(t *testing.T) {
+	tests := []struct {
+		name       string
+		soa        Soa
+		domains    *Domains
+		want       []byte
+		wantLength int
+	}{
+		{
+			name: "Simple SOA record",
+			soa: Soa{
+				MName:   "ns1.example.com",
+				RName:   "hostmaster.example.com",
+				Serial:  2018057217,
+				Refresh: 3600,
+				Retry:   1800,
+				Expire:  1209600,
+				Minimum: 3600,
+			},
+			domains: NewDomains(),
+			want: []byte("\x03ns1\x07example\x03com\x00\x0ahostmaster\x07example\x03com\x00" +
+				"\x78\x49\x1c\x01\x00\x00\x0e\x10\x00\x00\x07\x08\x00\x12\x75\x00\x00\x00\x0e\x10"),
+			wantLength: 61,
+		},
+		{
+			name: "Compressed SOA record",
+			soa: Soa{
+				MName:   "ns1.example.com",
+				RName:   "hostmaster.example.com",
+				Serial:  2018057217,
+				Refresh: 3600,
+				Retry:   1800,
+				Expire:  1209600,
+				Minimum: 3600,
+			},
+			domains: &Domains{buildPtr: map[string]int{"example.com": 12}},
+			want: []byte("\x03ns1\xc0\x0c\x0ahostmaster\xc0\x0c" +
+				"\x78\x49\x1c\x01\x00\x00\x0e\x10\x00\x00\x07\x08\x00\x12\x75\x00\x00\x00\x0e\x10"),
+			wantLength: 39,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if length, err := tt.soa.PreBuild(&Record{}, tt.domains); err != nil || length != tt.wantLength {
+				t.Errorf("Soa.PreBuild() = %v, %v, want %v", length, err, tt.wantLength)
+				return
+			}
+			if err := tt.soa.Build(buf, tt.domains); err != nil {
+				t.Errorf("Soa.Build() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(buf.Bytes(), tt.want) {
+				t.Errorf("Soa.Build() = %v, want %v", buf.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSerialCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		s1     uint32
+		s2     uint32
+		want   int
+		wantOk bool
+	}{
+		{name: "Equal", s1: 42, s2: 42, want: 0, wantOk: true},
+		{name: "Less", s1: 1, s2: 2, want: -1, wantOk: true},
+		{name: "Greater", s1: 2, s2: 1, want: 1, wantOk: true},
+		{name: "Wrapped greater", s1: 1, s2: 0xffffffff, want: 1, wantOk: true},
+		{name: "Wrapped less", s1: 0xfffffff0, s2: 0x10, want: -1, wantOk: true},
+		{name: "Undefined", s1: 0, s2: 0x80000000, want: 0, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SerialCompare(tt.s1, tt.s2)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("SerialCompare() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+			if less := SerialLess(tt.s1, tt.s2); less != (tt.want == -1) {
+				t.Errorf("SerialLess() = %v, want %v", less, tt.want == -1)
+			}
+		})
+	}
+}
+
+func TestSerialAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       uint32
+		n       uint32
+		want    uint32
+		wantErr bool
+	}{
+		{name: "Simple addition", s: 1, n: 1, want: 2},
+		{name: "Wrap around", s: 0xffffffff, n: 2, want: 1},
+		{name: "Out of range", s: 1, n: 0x80000000, want: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SerialAdd(tt.s, tt.n)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("SerialAdd() = %v, %v, want %v, wantErr %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSoa_IncrementSerial(t *testing.T) {
+	s := &Soa{Serial: 0xffffffff}
+	s.IncrementSerial()
+	if s.Serial != 0 {
+		t.Errorf("Soa.IncrementSerial() = %v, want %v", s.Serial, 0)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"strings"
 )
 
@@ -41,7 +42,7 @@ func (s *Srv) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
 	}
 	name, err := ParseName(buf, ptr+6, domains)
 	if err != nil {
-		return errors.New("unable to parse Srv: " + err.Error())
+		return fmt.Errorf("unable to parse Srv: %w", err)
 	}
 	s.Target = name
 	return nil
@@ -65,7 +66,10 @@ func (s *Srv) Build(buf *bytes.Buffer, domains *Domains) error {
 
 // PreBuild step, building name and adding full record
 func (s *Srv) PreBuild(_ *Record, domains *Domains) (int, error) {
-	s.targetBytes = BuildName(s.Target, domains)
+	var err error
+	if s.targetBytes, err = buildName(s.Target, domains); err != nil {
+		return 0, err
+	}
 	return len(s.targetBytes) + 6, nil
 }
 
@@ -80,7 +84,7 @@ func (s *Srv) TransformName(_ string) string {
 }
 
 func (s *Srv) parseName() error {
-	parts := strings.Split(s.NameBytes, ".")
+	parts := nameLabels(s.NameBytes)
 	pLen := len(parts)
 	if pLen < 3 {
 		return errors.New("not enough name parts of SRV record in: " + s.NameBytes)
@@ -97,3 +101,25 @@ func (s *Srv) parseName() error {
 
 	return nil
 }
+
+// parseText implements presentation format parsing of SRV records
+func (s *Srv) parseText(t *rdataText) error {
+	if err := s.parseName(); err != nil {
+		return err
+	}
+	var err error
+	for _, field := range []*uint16{&s.Priority, &s.Weight, &s.Port} {
+		if *field, err = t.uint16("SRV field"); err != nil {
+			return err
+		}
+	}
+	s.Target, err = t.name("target")
+	return err
+}
+
+// String returns the RDATA in presentation format
+func (s *Srv) String() string { return s.format(FormatOptions{}) }
+
+func (s *Srv) format(opts FormatOptions) string {
+	return fmt.Sprintf("%d %d %d %s", s.Priority, s.Weight, s.Port, opts.name(s.Target))
+}
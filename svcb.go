@@ -0,0 +1,656 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SvcParamKey is the key of a SVCB/HTTPS service parameter (RFC 9460)
+type SvcParamKey uint16
+
+// List of all registered SvcParamKey constants
+const (
+	SvcParamMandatory     SvcParamKey = 0
+	SvcParamAlpn          SvcParamKey = 1
+	SvcParamNoDefaultAlpn SvcParamKey = 2
+	SvcParamPort          SvcParamKey = 3
+	SvcParamIPv4Hint      SvcParamKey = 4
+	SvcParamECH           SvcParamKey = 5
+	SvcParamIPv6Hint      SvcParamKey = 6
+	SvcParamDoHPath       SvcParamKey = 7
+)
+
+// SvcParamKeyStrings holds the presentation names of the SvcParamKey constants
+var SvcParamKeyStrings = map[SvcParamKey]string{
+	SvcParamMandatory:     "mandatory",
+	SvcParamAlpn:          "alpn",
+	SvcParamNoDefaultAlpn: "no-default-alpn",
+	SvcParamPort:          "port",
+	SvcParamIPv4Hint:      "ipv4hint",
+	SvcParamECH:           "ech",
+	SvcParamIPv6Hint:      "ipv6hint",
+	SvcParamDoHPath:       "dohpath",
+}
+
+// String returns the presentation name of the key, using the generic keyNNNNN
+// form for unregistered keys
+func (k SvcParamKey) String() string {
+	if s, ok := SvcParamKeyStrings[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("key%d", uint16(k))
+}
+
+// SvcParam is a single service parameter of a SVCB or HTTPS record
+type SvcParam interface {
+	Key() SvcParamKey
+	Parse([]byte) error
+	Build(*bytes.Buffer) error
+}
+
+// Svcb implements interface RData for both SVCB and HTTPS records
+type Svcb struct {
+	Priority    uint16
+	Target      string
+	Params      []SvcParam
+	length      uint16
+	targetBytes string
+	paramBytes  []byte
+}
+
+// Parse implements SVCB parsing for interface RData
+func (s *Svcb) Parse(buf *bytes.Buffer, ptr int, domains *Domains) error {
+	bufLen := buf.Len()
+	if err := binary.Read(buf, binary.BigEndian, &s.Priority); err != nil {
+		return err
+	}
+	name, err := ParseName(buf, ptr+2, domains)
+	if err != nil {
+		return fmt.Errorf("unable to parse SVCB: %w", err)
+	}
+	s.Target = name
+
+	readLen := int(s.length) - (bufLen - buf.Len())
+	if readLen < 0 || readLen > buf.Len() {
+		return fmt.Errorf("SVCB params length out of range: %d", readLen)
+	}
+	params := bytes.NewBuffer(buf.Next(readLen))
+	s.Params = nil
+	for params.Len() > 0 {
+		var key SvcParamKey
+		var length uint16
+		if err := binary.Read(params, binary.BigEndian, &key); err != nil {
+			return fmt.Errorf("unable to read SVCB param key: %w", err)
+		}
+		if err := binary.Read(params, binary.BigEndian, &length); err != nil {
+			return fmt.Errorf("unable to read SVCB param length: %w", err)
+		}
+		if int(length) > params.Len() {
+			return fmt.Errorf("SVCB param %s too long: %d > %d", key, length, params.Len())
+		}
+		if n := len(s.Params); n > 0 && s.Params[n-1].Key() >= key {
+			return fmt.Errorf("SVCB param keys not in ascending order: %s after %s",
+				key, s.Params[n-1].Key())
+		}
+		param := newSvcParam(key)
+		if err := param.Parse(params.Next(int(length))); err != nil {
+			return fmt.Errorf("unable to parse SVCB param %s: %w", key, err)
+		}
+		s.Params = append(s.Params, param)
+	}
+	return nil
+}
+
+// Build implements SVCB building for interface RData
+func (s *Svcb) Build(buf *bytes.Buffer, _ *Domains) error {
+	if err := binary.Write(buf, binary.BigEndian, s.Priority); err != nil {
+		return err
+	}
+	buf.WriteString(s.targetBytes)
+	buf.Write(s.paramBytes)
+	return nil
+}
+
+// PreBuild validates the parameters and builds the target and parameters.
+// The target name is never compressed as required by RFC 9460.
+func (s *Svcb) PreBuild(_ *Record, _ *Domains) (int, error) {
+	if err := s.validate(); err != nil {
+		return 0, err
+	}
+	var err error
+	if s.targetBytes, err = buildName(s.Target, nil); err != nil {
+		return 0, err
+	}
+	var params, value bytes.Buffer
+	for _, p := range s.Params {
+		value.Reset()
+		if err := p.Build(&value); err != nil {
+			return 0, fmt.Errorf("unable to build SVCB param %s: %w", p.Key(), err)
+		}
+		if value.Len() > 0xffff {
+			return 0, fmt.Errorf("SVCB param %s too long: %d", p.Key(), value.Len())
+		}
+		binary.Write(&params, binary.BigEndian, p.Key())
+		binary.Write(&params, binary.BigEndian, uint16(value.Len()))
+		params.Write(value.Bytes())
+	}
+	s.paramBytes = params.Bytes()
+	return 2 + len(s.targetBytes) + len(s.paramBytes), nil
+}
+
+// TransformName satisfies the interface
+func (*Svcb) TransformName(name string) string { return name }
+
+// AliasMode reports whether the record is in AliasMode (priority 0)
+func (s *Svcb) AliasMode() bool {
+	return s.Priority == 0
+}
+
+// Param returns the parameter with the given key if present
+func (s *Svcb) Param(key SvcParamKey) (SvcParam, bool) {
+	for _, p := range s.Params {
+		if p.Key() == key {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// validate checks the AliasMode and ServiceMode rules of RFC 9460 section 2.4
+// and section 8 before building
+func (s *Svcb) validate() error {
+	if s.AliasMode() {
+		if len(s.Params) > 0 {
+			return errors.New("SVCB in AliasMode must not have params")
+		}
+		return nil
+	}
+	for i, p := range s.Params {
+		if i > 0 && s.Params[i-1].Key() >= p.Key() {
+			return fmt.Errorf("SVCB param keys not in ascending order: %s after %s",
+				p.Key(), s.Params[i-1].Key())
+		}
+	}
+	if p, ok := s.Param(SvcParamMandatory); ok {
+		mandatory, ok := p.(*SvcMandatory)
+		if !ok {
+			return errors.New("SVCB mandatory param has wrong type")
+		}
+		for _, key := range mandatory.Keys {
+			if key == SvcParamMandatory {
+				return errors.New("SVCB mandatory param must not list itself")
+			}
+			if _, ok := s.Param(key); !ok {
+				return fmt.Errorf("SVCB mandatory key %s is missing", key)
+			}
+		}
+	}
+	if _, ok := s.Param(SvcParamNoDefaultAlpn); ok {
+		if _, ok := s.Param(SvcParamAlpn); !ok {
+			return errors.New("SVCB no-default-alpn requires alpn")
+		}
+	}
+	return nil
+}
+
+func newSvcParam(key SvcParamKey) SvcParam {
+	switch key {
+	case SvcParamMandatory:
+		return &SvcMandatory{}
+	case SvcParamAlpn:
+		return &SvcAlpn{}
+	case SvcParamNoDefaultAlpn:
+		return &SvcNoDefaultAlpn{}
+	case SvcParamPort:
+		return &SvcPort{}
+	case SvcParamIPv4Hint:
+		return &SvcIPv4Hint{}
+	case SvcParamECH:
+		return &SvcECH{}
+	case SvcParamIPv6Hint:
+		return &SvcIPv6Hint{}
+	case SvcParamDoHPath:
+		return &SvcDoHPath{}
+	default:
+		return &SvcOpaque{KeyCode: key}
+	}
+}
+
+// SvcMandatory lists the keys a client must understand to use the record
+type SvcMandatory struct {
+	Keys []SvcParamKey
+}
+
+// Key returns the SvcParamKey of the param
+func (*SvcMandatory) Key() SvcParamKey { return SvcParamMandatory }
+
+// Parse implements parsing of the param value
+func (p *SvcMandatory) Parse(b []byte) error {
+	if len(b) == 0 || len(b)%2 != 0 {
+		return fmt.Errorf("invalid mandatory length: %d", len(b))
+	}
+	p.Keys = make([]SvcParamKey, 0, len(b)/2)
+	for i := 0; i < len(b); i += 2 {
+		key := SvcParamKey(binary.BigEndian.Uint16(b[i:]))
+		if n := len(p.Keys); n > 0 && p.Keys[n-1] >= key {
+			return errors.New("mandatory keys not in ascending order")
+		}
+		p.Keys = append(p.Keys, key)
+	}
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcMandatory) Build(buf *bytes.Buffer) error {
+	if len(p.Keys) == 0 {
+		return errors.New("mandatory must list at least one key")
+	}
+	for i, key := range p.Keys {
+		if i > 0 && p.Keys[i-1] >= key {
+			return errors.New("mandatory keys not in ascending order")
+		}
+		binary.Write(buf, binary.BigEndian, key)
+	}
+	return nil
+}
+
+// SvcAlpn lists the supported ALPN protocol identifiers
+type SvcAlpn struct {
+	IDs []string
+}
+
+// Key returns the SvcParamKey of the param
+func (*SvcAlpn) Key() SvcParamKey { return SvcParamAlpn }
+
+// Parse implements parsing of the param value
+func (p *SvcAlpn) Parse(b []byte) error {
+	if len(b) == 0 {
+		return errors.New("alpn must not be empty")
+	}
+	p.IDs = nil
+	for len(b) > 0 {
+		length := int(b[0])
+		if length == 0 || length+1 > len(b) {
+			return fmt.Errorf("invalid alpn id length: %d", length)
+		}
+		p.IDs = append(p.IDs, string(b[1:length+1]))
+		b = b[length+1:]
+	}
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcAlpn) Build(buf *bytes.Buffer) error {
+	if len(p.IDs) == 0 {
+		return errors.New("alpn must list at least one id")
+	}
+	for _, id := range p.IDs {
+		if len(id) == 0 || len(id) > 255 {
+			return fmt.Errorf("invalid alpn id length: %d", len(id))
+		}
+		buf.WriteByte(uint8(len(id)))
+		buf.WriteString(id)
+	}
+	return nil
+}
+
+// SvcNoDefaultAlpn signals that the default ALPN of the scheme is unsupported
+type SvcNoDefaultAlpn struct{}
+
+// Key returns the SvcParamKey of the param
+func (*SvcNoDefaultAlpn) Key() SvcParamKey { return SvcParamNoDefaultAlpn }
+
+// Parse implements parsing of the param value
+func (*SvcNoDefaultAlpn) Parse(b []byte) error {
+	if len(b) != 0 {
+		return fmt.Errorf("no-default-alpn must be empty, got %d bytes", len(b))
+	}
+	return nil
+}
+
+// Build implements building of the param value
+func (*SvcNoDefaultAlpn) Build(_ *bytes.Buffer) error { return nil }
+
+// SvcPort holds the alternative port of the service
+type SvcPort struct {
+	Port uint16
+}
+
+// Key returns the SvcParamKey of the param
+func (*SvcPort) Key() SvcParamKey { return SvcParamPort }
+
+// Parse implements parsing of the param value
+func (p *SvcPort) Parse(b []byte) error {
+	if len(b) != 2 {
+		return fmt.Errorf("invalid port length: %d", len(b))
+	}
+	p.Port = binary.BigEndian.Uint16(b)
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcPort) Build(buf *bytes.Buffer) error {
+	return binary.Write(buf, binary.BigEndian, p.Port)
+}
+
+// SvcIPv4Hint holds IPv4 address hints for the service
+type SvcIPv4Hint struct {
+	Addrs []netip.Addr
+}
+
+// Key returns the SvcParamKey of the param
+func (*SvcIPv4Hint) Key() SvcParamKey { return SvcParamIPv4Hint }
+
+// Parse implements parsing of the param value
+func (p *SvcIPv4Hint) Parse(b []byte) error {
+	if len(b) == 0 || len(b)%4 != 0 {
+		return fmt.Errorf("invalid ipv4hint length: %d", len(b))
+	}
+	p.Addrs = make([]netip.Addr, 0, len(b)/4)
+	for i := 0; i < len(b); i += 4 {
+		p.Addrs = append(p.Addrs, netip.AddrFrom4([4]byte(b[i:i+4])))
+	}
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcIPv4Hint) Build(buf *bytes.Buffer) error {
+	if len(p.Addrs) == 0 {
+		return errors.New("ipv4hint must list at least one address")
+	}
+	for _, addr := range p.Addrs {
+		if !addr.Is4() {
+			return fmt.Errorf("not an IPv4 address: %s", addr)
+		}
+		a := addr.As4()
+		buf.Write(a[:])
+	}
+	return nil
+}
+
+// SvcECH holds an ECHConfigList for Encrypted Client Hello
+type SvcECH struct {
+	Config []byte
+}
+
+// Key returns the SvcParamKey of the param
+func (*SvcECH) Key() SvcParamKey { return SvcParamECH }
+
+// Parse implements parsing of the param value
+func (p *SvcECH) Parse(b []byte) error {
+	p.Config = append([]byte(nil), b...)
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcECH) Build(buf *bytes.Buffer) error {
+	buf.Write(p.Config)
+	return nil
+}
+
+// SvcIPv6Hint holds IPv6 address hints for the service
+type SvcIPv6Hint struct {
+	Addrs []netip.Addr
+}
+
+// Key returns the SvcParamKey of the param
+func (*SvcIPv6Hint) Key() SvcParamKey { return SvcParamIPv6Hint }
+
+// Parse implements parsing of the param value
+func (p *SvcIPv6Hint) Parse(b []byte) error {
+	if len(b) == 0 || len(b)%16 != 0 {
+		return fmt.Errorf("invalid ipv6hint length: %d", len(b))
+	}
+	p.Addrs = make([]netip.Addr, 0, len(b)/16)
+	for i := 0; i < len(b); i += 16 {
+		p.Addrs = append(p.Addrs, netip.AddrFrom16([16]byte(b[i:i+16])))
+	}
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcIPv6Hint) Build(buf *bytes.Buffer) error {
+	if len(p.Addrs) == 0 {
+		return errors.New("ipv6hint must list at least one address")
+	}
+	for _, addr := range p.Addrs {
+		if !addr.Is6() || addr.Is4In6() {
+			return fmt.Errorf("not an IPv6 address: %s", addr)
+		}
+		a := addr.As16()
+		buf.Write(a[:])
+	}
+	return nil
+}
+
+// SvcDoHPath holds the URI template for DNS over HTTPS (RFC 9461)
+type SvcDoHPath struct {
+	Template string
+}
+
+// Key returns the SvcParamKey of the param
+func (*SvcDoHPath) Key() SvcParamKey { return SvcParamDoHPath }
+
+// Parse implements parsing of the param value
+func (p *SvcDoHPath) Parse(b []byte) error {
+	p.Template = string(b)
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcDoHPath) Build(buf *bytes.Buffer) error {
+	buf.WriteString(p.Template)
+	return nil
+}
+
+// SvcOpaque holds the raw value of a param without a specific implementation
+type SvcOpaque struct {
+	KeyCode SvcParamKey
+	Value   []byte
+}
+
+// Key returns the SvcParamKey of the param
+func (p *SvcOpaque) Key() SvcParamKey { return p.KeyCode }
+
+// Parse implements parsing of the param value
+func (p *SvcOpaque) Parse(b []byte) error {
+	p.Value = append([]byte(nil), b...)
+	return nil
+}
+
+// Build implements building of the param value
+func (p *SvcOpaque) Build(buf *bytes.Buffer) error {
+	buf.Write(p.Value)
+	return nil
+}
+
+// parseText implements presentation format parsing of SVCB and HTTPS records
+// as described in RFC 9460 section 2.1
+func (s *Svcb) parseText(t *rdataText) error {
+	var err error
+	if s.Priority, err = t.uint16("priority"); err != nil {
+		return err
+	}
+	if s.Target, err = t.name("target"); err != nil {
+		return err
+	}
+	for t.more() {
+		field, _ := t.next("param")
+		key, value, hasValue := strings.Cut(field, "=")
+		param, err := parseSvcParamText(key, value, hasValue)
+		if err != nil {
+			return err
+		}
+		s.Params = append(s.Params, param)
+	}
+	sort.SliceStable(s.Params, func(i, j int) bool {
+		return s.Params[i].Key() < s.Params[j].Key()
+	})
+	return s.validate()
+}
+
+// parseSvcParamKey parses a key name or the generic keyNNNNN form
+func parseSvcParamKey(s string) (SvcParamKey, error) {
+	for key, name := range SvcParamKeyStrings {
+		if name == s {
+			return key, nil
+		}
+	}
+	if strings.HasPrefix(s, "key") {
+		if v, err := strconv.ParseUint(s[3:], 10, 16); err == nil {
+			return SvcParamKey(v), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown SvcParamKey %q", s)
+}
+
+// parseSvcParamText parses the presentation format of a single param
+func parseSvcParamText(keyName, raw string, hasValue bool) (SvcParam, error) {
+	key, err := parseSvcParamKey(keyName)
+	if err != nil {
+		return nil, err
+	}
+	value, err := unescapeText(raw)
+	if err != nil {
+		return nil, err
+	}
+	if key == SvcParamNoDefaultAlpn {
+		if hasValue {
+			return nil, errors.New("no-default-alpn takes no value")
+		}
+		return &SvcNoDefaultAlpn{}, nil
+	}
+	if _, registered := SvcParamKeyStrings[key]; registered && !hasValue {
+		return nil, fmt.Errorf("missing value for %s", key)
+	}
+
+	switch key {
+	case SvcParamMandatory:
+		p := &SvcMandatory{}
+		for _, item := range splitValueList(value) {
+			k, err := parseSvcParamKey(item)
+			if err != nil {
+				return nil, err
+			}
+			p.Keys = append(p.Keys, k)
+		}
+		sort.Slice(p.Keys, func(i, j int) bool { return p.Keys[i] < p.Keys[j] })
+		return p, nil
+	case SvcParamAlpn:
+		return &SvcAlpn{IDs: splitValueList(value)}, nil
+	case SvcParamPort:
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", value)
+		}
+		return &SvcPort{Port: uint16(port)}, nil
+	case SvcParamIPv4Hint, SvcParamIPv6Hint:
+		var addrs []netip.Addr
+		for _, item := range splitValueList(value) {
+			addr, err := netip.ParseAddr(item)
+			if err != nil || addr.Is4() != (key == SvcParamIPv4Hint) {
+				return nil, fmt.Errorf("invalid %s address %q", key, item)
+			}
+			addrs = append(addrs, addr)
+		}
+		if key == SvcParamIPv4Hint {
+			return &SvcIPv4Hint{Addrs: addrs}, nil
+		}
+		return &SvcIPv6Hint{Addrs: addrs}, nil
+	case SvcParamECH:
+		config, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ech: %w", err)
+		}
+		return &SvcECH{Config: config}, nil
+	case SvcParamDoHPath:
+		return &SvcDoHPath{Template: value}, nil
+	default:
+		return &SvcOpaque{KeyCode: key, Value: []byte(value)}, nil
+	}
+}
+
+// splitValueList splits a comma separated value list, where commas and
+// backslashes within items are escaped by a backslash (RFC 9460 appendix A.1)
+func splitValueList(s string) []string {
+	var items []string
+	var item strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			item.WriteByte(s[i])
+		case s[i] == ',':
+			items = append(items, item.String())
+			item.Reset()
+		default:
+			item.WriteByte(s[i])
+		}
+	}
+	return append(items, item.String())
+}
+
+// String returns the RDATA in presentation format
+func (s *Svcb) String() string { return s.format(FormatOptions{}) }
+
+func (s *Svcb) format(opts FormatOptions) string {
+	parts := []string{strconv.Itoa(int(s.Priority)), opts.name(s.Target)}
+	for _, p := range s.Params {
+		parts = append(parts, svcParamString(p))
+	}
+	return strings.Join(parts, " ")
+}
+
+// svcParamString returns the key=value presentation format of a param
+func svcParamString(p SvcParam) string {
+	key := p.Key().String()
+	switch p := p.(type) {
+	case *SvcMandatory:
+		keys := make([]string, len(p.Keys))
+		for i, k := range p.Keys {
+			keys[i] = k.String()
+		}
+		return key + "=" + strings.Join(keys, ",")
+	case *SvcAlpn:
+		ids := make([]string, len(p.IDs))
+		for i, id := range p.IDs {
+			ids[i] = strings.NewReplacer(`\`, `\\`, `,`, `\,`).Replace(id)
+		}
+		return key + "=" + quoteText(strings.Join(ids, ","))
+	case *SvcNoDefaultAlpn:
+		return key
+	case *SvcPort:
+		return key + "=" + strconv.Itoa(int(p.Port))
+	case *SvcIPv4Hint:
+		return key + "=" + addrsString(p.Addrs)
+	case *SvcIPv6Hint:
+		return key + "=" + addrsString(p.Addrs)
+	case *SvcECH:
+		return key + "=" + base64.StdEncoding.EncodeToString(p.Config)
+	case *SvcDoHPath:
+		return key + "=" + quoteText(p.Template)
+	case *SvcOpaque:
+		if len(p.Value) == 0 {
+			return key
+		}
+		return key + "=" + quoteText(string(p.Value))
+	}
+	var buf bytes.Buffer
+	p.Build(&buf)
+	return key + "=" + quoteText(buf.String())
+}
+
+func addrsString(addrs []netip.Addr) string {
+	parts := make([]string, len(addrs))
+	for i, addr := range addrs {
+		parts[i] = addr.String()
+	}
+	return strings.Join(parts, ",")
+}
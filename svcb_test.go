@@ -0,0 +1,277 @@
+package dns
+
+import (
+	"bytes"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+// Wire format test vectors from RFC 9460 appendix D
+var (
+	svcbAliasWire   = []byte("\x00\x00\x03foo\x07example\x03com\x00")
+	svcbRootWire    = []byte("\x00\x01\x00")
+	svcbPortWire    = []byte("\x00\x10\x03foo\x07example\x03com\x00\x00\x03\x00\x02\x00\x35")
+	svcbOpaqueWire  = []byte("\x00\x01\x03foo\x07example\x03com\x00\x02\x9b\x00\x05hello")
+	svcbIPv6Wire    = []byte("\x00\x01\x03foo\x07example\x03com\x00\x00\x06\x00\x20\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x53\x00\x01")
+	svcbComplexWire = []byte("\x00\x10\x03foo\x07example\x03org\x00\x00\x00\x00\x04\x00\x01\x00\x04\x00\x01\x00\x09\x02h2\x05h3-19\x00\x04\x00\x04\xc0\x00\x02\x01")
+)
+
+func TestSvcb_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    *Svcb
+		wantErr bool
+	}{
+		{
+			name: "AliasMode",
+			buf:  svcbAliasWire,
+			want: &Svcb{Target: "foo.example.com"},
+		},
+		{
+			name: "ServiceMode root target",
+			buf:  svcbRootWire,
+			want: &Svcb{Priority: 1},
+		},
+		{
+			name: "Port param",
+			buf:  svcbPortWire,
+			want: &Svcb{
+				Priority: 16,
+				Target:   "foo.example.com",
+				Params:   []SvcParam{&SvcPort{Port: 53}},
+			},
+		},
+		{
+			name: "Unknown key",
+			buf:  svcbOpaqueWire,
+			want: &Svcb{
+				Priority: 1,
+				Target:   "foo.example.com",
+				Params:   []SvcParam{&SvcOpaque{KeyCode: 667, Value: []byte("hello")}},
+			},
+		},
+		{
+			name: "IPv6 hints",
+			buf:  svcbIPv6Wire,
+			want: &Svcb{
+				Priority: 1,
+				Target:   "foo.example.com",
+				Params: []SvcParam{&SvcIPv6Hint{Addrs: []netip.Addr{
+					netip.MustParseAddr("2001:db8::1"),
+					netip.MustParseAddr("2001:db8::53:1"),
+				}}},
+			},
+		},
+		{
+			name: "Mandatory, alpn and IPv4 hints",
+			buf:  svcbComplexWire,
+			want: &Svcb{
+				Priority: 16,
+				Target:   "foo.example.org",
+				Params: []SvcParam{
+					&SvcMandatory{Keys: []SvcParamKey{SvcParamAlpn, SvcParamIPv4Hint}},
+					&SvcAlpn{IDs: []string{"h2", "h3-19"}},
+					&SvcIPv4Hint{Addrs: []netip.Addr{netip.MustParseAddr("192.0.2.1")}},
+				},
+			},
+		},
+		{
+			name:    "Keys in descending order",
+			buf:     []byte("\x00\x01\x00\x00\x03\x00\x02\x00\x35\x00\x01\x00\x03\x02h2"),
+			wantErr: true,
+		},
+		{
+			name:    "Param value too long",
+			buf:     []byte("\x00\x01\x00\x00\x03\x00\x04\x00\x35"),
+			wantErr: true,
+		},
+		{
+			name:    "Bad port length",
+			buf:     []byte("\x00\x01\x00\x00\x03\x00\x01\x35"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Svcb{length: uint16(len(tt.buf))}
+			if err := s.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Svcb.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			tt.want.length = uint16(len(tt.buf))
+			if !reflect.DeepEqual(s, tt.want) {
+				t.Errorf("Svcb.Parse() = %+v, want %+v", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestSvcb_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		svcb    *Svcb
+		want    []byte
+		wantErr bool
+	}{
+		{
+			name: "AliasMode",
+			svcb: &Svcb{Target: "foo.example.com"},
+			want: svcbAliasWire,
+		},
+		{
+			name: "ServiceMode root target",
+			svcb: &Svcb{Priority: 1},
+			want: svcbRootWire,
+		},
+		{
+			name: "Port param",
+			svcb: &Svcb{
+				Priority: 16,
+				Target:   "foo.example.com",
+				Params:   []SvcParam{&SvcPort{Port: 53}},
+			},
+			want: svcbPortWire,
+		},
+		{
+			name: "Unknown key",
+			svcb: &Svcb{
+				Priority: 1,
+				Target:   "foo.example.com",
+				Params:   []SvcParam{&SvcOpaque{KeyCode: 667, Value: []byte("hello")}},
+			},
+			want: svcbOpaqueWire,
+		},
+		{
+			name: "Mandatory, alpn and IPv4 hints",
+			svcb: &Svcb{
+				Priority: 16,
+				Target:   "foo.example.org",
+				Params: []SvcParam{
+					&SvcMandatory{Keys: []SvcParamKey{SvcParamAlpn, SvcParamIPv4Hint}},
+					&SvcAlpn{IDs: []string{"h2", "h3-19"}},
+					&SvcIPv4Hint{Addrs: []netip.Addr{netip.MustParseAddr("192.0.2.1")}},
+				},
+			},
+			want: svcbComplexWire,
+		},
+		{
+			name: "AliasMode with params",
+			svcb: &Svcb{
+				Target: "foo.example.com",
+				Params: []SvcParam{&SvcPort{Port: 53}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Keys out of order",
+			svcb: &Svcb{
+				Priority: 1,
+				Params:   []SvcParam{&SvcPort{Port: 53}, &SvcAlpn{IDs: []string{"h2"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Duplicate keys",
+			svcb: &Svcb{
+				Priority: 1,
+				Params:   []SvcParam{&SvcPort{Port: 53}, &SvcPort{Port: 54}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Missing mandatory key",
+			svcb: &Svcb{
+				Priority: 1,
+				Params: []SvcParam{
+					&SvcMandatory{Keys: []SvcParamKey{SvcParamAlpn}},
+					&SvcPort{Port: 53},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Mandatory listing itself",
+			svcb: &Svcb{
+				Priority: 1,
+				Params: []SvcParam{
+					&SvcMandatory{Keys: []SvcParamKey{SvcParamMandatory}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Mandatory param of the wrong type",
+			svcb: &Svcb{
+				Priority: 1,
+				Params: []SvcParam{
+					&SvcOpaque{KeyCode: SvcParamMandatory, Value: []byte{0, 1}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "No default alpn without alpn",
+			svcb: &Svcb{
+				Priority: 1,
+				Params:   []SvcParam{&SvcNoDefaultAlpn{}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "IPv6 address in IPv4 hint",
+			svcb: &Svcb{
+				Priority: 1,
+				Params:   []SvcParam{&SvcIPv4Hint{Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")}}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			domains := NewDomains()
+			domains.SetBuild(0, "foo.example.com")
+			length, err := tt.svcb.PreBuild(&Record{}, domains)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Svcb.PreBuild() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if length != len(tt.want) {
+				t.Errorf("Svcb.PreBuild() = %v, want %v", length, len(tt.want))
+			}
+			if err := tt.svcb.Build(buf, domains); err != nil {
+				t.Errorf("Svcb.Build() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(buf.Bytes(), tt.want) {
+				t.Errorf("Svcb.Build() = %v, want %v", buf.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSvcParamKey_String(t *testing.T) {
+	tests := []struct {
+		key  SvcParamKey
+		want string
+	}{
+		{key: SvcParamAlpn, want: "alpn"},
+		{key: SvcParamDoHPath, want: "dohpath"},
+		{key: 667, want: "key667"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.key.String(); got != tt.want {
+				t.Errorf("SvcParamKey.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
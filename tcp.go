@@ -0,0 +1,190 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// Errors returned when building and exchanging messages
+var (
+	ErrMessageTooLarge = errors.New("dns: message too large")
+	ErrConnClosed      = errors.New("dns: connection closed")
+)
+
+// WriteMessage builds m and writes it to w with the 2 byte length prefix used
+// for DNS over TCP (RFC 1035 section 4.2.2). The message is written in a single
+// call, so concurrent writers only need to serialize calls to WriteMessage.
+func WriteMessage(w io.Writer, m *Message) error {
+	// Names are compressed with offsets from the start of the message, so it
+	// is built separately from the length prefix
+	msg := new(bytes.Buffer)
+	domains := AcquireDomains()
+	defer domains.Release()
+	if err := m.Build(msg, domains); err != nil {
+		return err
+	}
+	if msg.Len() > maxMessageSize {
+		return fmt.Errorf("%w: %d bytes", ErrMessageTooLarge, msg.Len())
+	}
+	framed := make([]byte, 2, 2+msg.Len())
+	binary.BigEndian.PutUint16(framed, uint16(msg.Len()))
+	_, err := w.Write(append(framed, msg.Bytes()...))
+	return err
+}
+
+// ReadMessage reads a single length prefixed message from r. It can be called
+// repeatedly to read a stream of messages from a TCP connection. io.EOF is
+// returned if the stream ends between messages.
+func ReadMessage(r io.Reader) (*Message, error) {
+	frame, err := readFrame(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMessage(bytes.NewBuffer(frame))
+}
+
+// readFrame reads the raw bytes of a length prefixed message
+func readFrame(r io.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	frame := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+// Conn is a DNS over TCP connection supporting pipelined queries. Queries may
+// be sent concurrently, and responses are matched to their queries by ID in
+// whatever order the server sends them (RFC 7766 section 6.2.1.1).
+type Conn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[uint16]*pendingQuery
+	err     error
+	done    chan struct{}
+}
+
+type pendingQuery struct {
+	query *Message
+	resp  chan *Message
+}
+
+// NewConn starts reading responses from conn. The connection is owned by the
+// returned Conn and closed by Close.
+func NewConn(conn net.Conn) *Conn {
+	c := &Conn{
+		conn:    conn,
+		pending: map[uint16]*pendingQuery{},
+		done:    make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// Exchange sends m on the connection and waits for the response. A free random
+// ID is assigned to m if it has none, and an error is returned if its ID is
+// already in use by another pending query.
+func (c *Conn) Exchange(ctx context.Context, m *Message) (*Message, error) {
+	p := &pendingQuery{query: m, resp: make(chan *Message, 1)}
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	if m.ID == 0 {
+		m.ID = c.freeID()
+	} else if _, ok := c.pending[m.ID]; ok {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("dns: query ID %d already in use", m.ID)
+	}
+	c.pending[m.ID] = p
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		if c.pending[m.ID] == p {
+			delete(c.pending, m.ID)
+		}
+		c.mu.Unlock()
+	}()
+
+	c.writeMu.Lock()
+	err := WriteMessage(c.conn, m)
+	c.writeMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-p.resp:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.done:
+		return nil, c.err
+	}
+}
+
+// freeID returns a random ID not used by a pending query, c.mu must be held
+func (c *Conn) freeID() uint16 {
+	var b [2]byte
+	for {
+		rand.Read(b[:])
+		id := binary.BigEndian.Uint16(b[:])
+		if _, ok := c.pending[id]; !ok && id != 0 {
+			return id
+		}
+	}
+}
+
+// readLoop dispatches responses to pending queries until the connection fails.
+// Malformed messages and responses to unknown queries are dropped, as the
+// framing still allows the following messages to be read.
+func (c *Conn) readLoop() {
+	for {
+		frame, err := readFrame(c.conn)
+		if err != nil {
+			c.mu.Lock()
+			if c.err == nil {
+				c.err = fmt.Errorf("%w: %w", ErrConnClosed, err)
+			}
+			c.mu.Unlock()
+			close(c.done)
+			return
+		}
+		resp, err := ParseMessage(bytes.NewBuffer(frame))
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		if p, ok := c.pending[resp.ID]; ok && isResponseTo(p.query, resp) {
+			delete(c.pending, resp.ID)
+			p.resp <- resp
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Close closes the connection, failing all pending queries
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = ErrConnClosed
+	}
+	c.mu.Unlock()
+	return c.conn.Close()
+}
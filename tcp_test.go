@@ -0,0 +1,142 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWriteReadMessage(t *testing.T) {
+	stream := new(bytes.Buffer)
+	first, second := testQuery(), testQuery()
+	first.ID, second.ID = 1, 2
+	second.Questions[0].Domain = "example.org"
+	for _, m := range []*Message{first, second} {
+		if err := WriteMessage(stream, m); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+	}
+	if got := int(stream.Bytes()[0])<<8 | int(stream.Bytes()[1]); got != 29 {
+		t.Errorf("WriteMessage() length prefix = %d, want 29", got)
+	}
+	for _, want := range []*Message{first, second} {
+		got, err := ReadMessage(stream)
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v", err)
+		}
+		if got.ID != want.ID || got.Questions[0].Domain != want.Questions[0].Domain {
+			t.Errorf("ReadMessage() = %v, want %v", got, want)
+		}
+	}
+	if _, err := ReadMessage(stream); err != io.EOF {
+		t.Errorf("ReadMessage() at end of stream error = %v, want %v", err, io.EOF)
+	}
+}
+
+func TestReadMessage_Truncated(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "Partial length", data: []byte{0}},
+		{name: "Partial message", data: []byte{0, 12, 0, 1, 0, 0}},
+		{name: "Missing message", data: []byte{0, 12}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadMessage(bytes.NewReader(tt.data))
+			if err != io.ErrUnexpectedEOF {
+				t.Errorf("ReadMessage() error = %v, want %v", err, io.ErrUnexpectedEOF)
+			}
+		})
+	}
+}
+
+func TestConn_Exchange(t *testing.T) {
+	client, server := net.Pipe()
+	conn := NewConn(client)
+	defer conn.Close()
+
+	// Answer the queries in reverse order once both have arrived, with an
+	// unsolicited response and a malformed message first
+	go func() {
+		first, err := ReadMessage(server)
+		if err != nil {
+			return
+		}
+		second, err := ReadMessage(server)
+		if err != nil {
+			return
+		}
+		unknown := testAnswer(first, "198.51.100.1")
+		unknown.ID = first.ID ^ second.ID ^ 0xffff
+		WriteMessage(server, unknown)
+		server.Write([]byte{0, 2, 0, 0})
+		WriteMessage(server, testAnswer(second, "192.0.2.2"))
+		WriteMessage(server, testAnswer(first, "192.0.2.1"))
+	}()
+
+	results := make(chan string, 2)
+	for _, domain := range []string{"one.example", "two.example"} {
+		go func() {
+			q := testQuery()
+			q.Questions[0].Domain = domain
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			resp, err := conn.Exchange(ctx, q)
+			if err != nil {
+				results <- err.Error()
+				return
+			}
+			results <- resp.Questions[0].Domain
+		}()
+	}
+	got := map[string]bool{<-results: true, <-results: true}
+	for _, want := range []string{"one.example", "two.example"} {
+		if !got[want] {
+			t.Errorf("Conn.Exchange() results = %v, want response for %s", got, want)
+		}
+	}
+}
+
+func TestConn_Closed(t *testing.T) {
+	client, server := net.Pipe()
+	conn := NewConn(client)
+	go func() {
+		ReadMessage(server)
+		server.Close()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := conn.Exchange(ctx, testQuery()); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("Conn.Exchange() error = %v, want %v", err, ErrConnClosed)
+	}
+	conn.Close()
+	if _, err := conn.Exchange(ctx, testQuery()); !errors.Is(err, ErrConnClosed) {
+		t.Errorf("Conn.Exchange() after Close error = %v, want %v", err, ErrConnClosed)
+	}
+}
+
+func TestConn_DuplicateID(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := NewConn(client)
+	defer conn.Close()
+	go ReadMessage(server)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	q := testQuery()
+	q.ID = 42
+	go conn.Exchange(ctx, q)
+	time.Sleep(10 * time.Millisecond)
+	dup := testQuery()
+	dup.ID = 42
+	if _, err := conn.Exchange(ctx, dup); err == nil {
+		t.Error("Conn.Exchange() with an ID in use, want error")
+	}
+}
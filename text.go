@@ -0,0 +1,131 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// nameString returns the presentation format of a name, which is always
+// absolute. Besides the escapes of the string form, characters with a meaning
+// in zone files are escaped.
+func nameString(name string) string {
+	if name == "" {
+		return "."
+	}
+	special := func(r rune) bool {
+		return r <= ' ' || r == utf8.RuneError || (r > '~' && !unicode.IsPrint(r)) ||
+			strings.ContainsRune(`\";()`, r)
+	}
+	if strings.IndexFunc(name, special) < 0 {
+		return name + "."
+	}
+	labels := nameLabels(name)
+	for i, label := range labels {
+		labels[i] = labelString(label)
+	}
+	return strings.Join(labels, ".") + "."
+}
+
+// labelString returns the presentation format of a single label of a name
+func labelString(label string) string {
+	var b strings.Builder
+	raw := []byte(rawLabel(label))
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if n := printableRune(raw[i:]); n > 0 {
+			b.Write(raw[i : i+n])
+			i += n - 1
+			continue
+		}
+		switch {
+		case strings.IndexByte(`.\";()`, c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c <= ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// escapeText escapes quotes, backslashes and non-printable bytes of a
+// character string as described in RFC 1035 section 5.1
+func escapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < ' ' || c > '~':
+			fmt.Fprintf(&b, "\\%03d", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// quoteText returns a character string as an escaped, quoted string
+func quoteText(s string) string {
+	return `"` + escapeText(s) + `"`
+}
+
+// typesString returns a list of types separated by spaces
+func typesString(types []Type) string {
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = t.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// genericString returns the generic \# form of RFC 3597 section 5
+func genericString(data []byte) string {
+	if len(data) == 0 {
+		return `\# 0`
+	}
+	return fmt.Sprintf(`\# %d %s`, len(data), hex.EncodeToString(data))
+}
+
+// String returns the record in presentation format, as a single line of a
+// zone file
+func (r Record) String() string {
+	return r.FormatWith(FormatOptions{})
+}
+
+// rdataString returns the presentation format of the RDATA of r, falling back
+// to the generic form for RData implementations without one
+func rdataString(r *Record, opts FormatOptions) string {
+	if r.Data == nil {
+		return ""
+	}
+	if f, ok := r.Data.(rdataFormatter); ok {
+		return f.format(opts)
+	}
+	if s, ok := r.Data.(fmt.Stringer); ok {
+		return s.String()
+	}
+	var buf bytes.Buffer
+	if _, err := r.Data.PreBuild(r, nil); err != nil {
+		return genericString(nil)
+	}
+	r.Data.Build(&buf, nil)
+	return genericString(buf.Bytes())
+}
+
+// String returns the question in presentation format
+func (q Question) String() string {
+	return q.format(FormatOptions{})
+}
+
+func (q Question) format(opts FormatOptions) string {
+	return fmt.Sprintf("%s\t%s\t%s", opts.name(q.Domain), q.Class, q.Type)
+}
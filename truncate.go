@@ -0,0 +1,141 @@
+package dns
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// minUDPSize is the largest UDP response to a query without EDNS
+const minUDPSize = 512
+
+// UDPSize returns the largest UDP response accepted by the sender of m, which
+// is the payload size of its OPT record or 512 bytes without EDNS (RFC 6891
+// section 6.2.5)
+func (m *Message) UDPSize() int {
+	if opt := m.Opt(); opt != nil && int(opt.UDPSize) > minUDPSize {
+		return int(opt.UDPSize)
+	}
+	return minUDPSize
+}
+
+// BuildWithLimit builds m in buf like Build, but drops whole RRsets from the
+// end of the message until it fits in maxSize bytes (RFC 2181 section 9). OPT
+// records are always kept, and signatures are kept or dropped with the RRset
+// they cover. TC is set on the wire if answer or authority records had to be
+// dropped, and a TC flag already set in m is kept. m itself is not modified.
+// Use m.UDPSize() of the query as maxSize when answering over UDP.
+//
+// A message that fits is built unchanged. Otherwise the records of every
+// section, including sections that are kept whole, are regrouped by RRset in
+// order of first appearance, and OPT records are moved to the end of the
+// additional section.
+func (m *Message) BuildWithLimit(buf *bytes.Buffer, domains *Domains, maxSize int) error {
+	start := buf.Len()
+	if err := m.Build(buf, domains); err != nil {
+		return err
+	}
+	if buf.Len()-start <= maxSize {
+		return nil
+	}
+	buf.Truncate(start)
+	if domains != nil {
+		clear(domains.buildPtr)
+	}
+	t, err := m.truncated(maxSize, domains != nil)
+	if err != nil {
+		return err
+	}
+	return t.Build(buf, domains)
+}
+
+// truncated returns a copy of m holding the RRsets that fit in maxSize bytes
+// when built with name compression if compress is set
+func (m *Message) truncated(maxSize int, compress bool) (*Message, error) {
+	t := *m
+	t.Answers, t.Nameservers, t.Additional = nil, nil, nil
+
+	var opts []Record
+	optLen := 0
+	for _, r := range m.Additional {
+		if r.Type == OPT {
+			b := new(bytes.Buffer)
+			if err := r.Build(b, nil); err != nil {
+				return nil, err
+			}
+			optLen += b.Len()
+			opts = append(opts, r)
+		}
+	}
+
+	// The header and questions are always kept, the records are measured by
+	// building them after the questions in the same order as the final message
+	buf := new(bytes.Buffer)
+	var domains *Domains
+	if compress {
+		domains = AcquireDomains()
+		defer domains.Release()
+	}
+	if err := t.Build(buf, domains); err != nil {
+		return nil, err
+	}
+	if buf.Len()+optLen > maxSize {
+		return nil, fmt.Errorf("%w: question does not fit in %d bytes", ErrMessageTooLarge,
+			maxSize)
+	}
+	sections := []*[]Record{&t.Answers, &t.Nameservers, &t.Additional}
+	for i, records := range [][]Record{m.Answers, m.Nameservers, m.Additional} {
+		kept, complete, err := fitRRsets(records, buf, domains, maxSize-optLen)
+		if err != nil {
+			return nil, err
+		}
+		*sections[i] = kept
+		if !complete {
+			t.TC = t.TC || i < 2
+			break
+		}
+	}
+	t.Additional = append(t.Additional, opts...)
+	return &t, nil
+}
+
+// fitRRsets builds the RRsets of records in buf while it stays within maxSize.
+// It returns the records of the RRsets that fit, grouped by RRset in order of
+// first appearance and leaving out OPT records, and whether all of them did.
+func fitRRsets(records []Record, buf *bytes.Buffer, domains *Domains, maxSize int,
+) ([]Record, bool, error) {
+	var order []rrsetKey
+	rrsets := map[rrsetKey][]Record{}
+	for _, r := range records {
+		if r.Type == OPT {
+			continue
+		}
+		key := truncationKey(r)
+		if _, ok := rrsets[key]; !ok {
+			order = append(order, key)
+		}
+		rrsets[key] = append(rrsets[key], r)
+	}
+	var kept []Record
+	for _, key := range order {
+		for _, r := range rrsets[key] {
+			if err := r.Build(buf, domains); err != nil {
+				return nil, false, err
+			}
+		}
+		if buf.Len() > maxSize {
+			return kept, false, nil
+		}
+		kept = append(kept, rrsets[key]...)
+	}
+	return kept, true, nil
+}
+
+// truncationKey returns the RRset of r, with signatures belonging to the RRset
+// they cover
+func truncationKey(r Record) rrsetKey {
+	t := r.Type
+	if sig, ok := r.Data.(*Rrsig); ok {
+		t = sig.TypeCovered
+	}
+	return rrsetKey{CanonicalName(r.Name), t, r.Class}
+}
@@ -0,0 +1,200 @@
+package dns
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+)
+
+// testRRset returns n A records for name
+func testRRset(name string, n int) []Record {
+	var records []Record
+	for i := 0; i < n; i++ {
+		records = append(records, Record{Name: name, Type: A, Class: uint16(IN), TTL: 60,
+			Data: &IPv4{netip.AddrFrom4([4]byte{192, 0, 2, byte(i)})}})
+	}
+	return records
+}
+
+func testSig(name string, covered Type) Record {
+	return Record{Name: name, Type: RRSIG, Class: uint16(IN), TTL: 60, Data: &Rrsig{
+		TypeCovered: covered, Algorithm: ECDSAP256SHA256, Labels: 2, OriginalTTL: 60,
+		SignerName: "example.com", Signature: make([]byte, 64)}}
+}
+
+// builtLen returns the length of m built with compression
+func builtLen(t *testing.T, m *Message) int {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if err := m.Build(buf, NewDomains()); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Len()
+}
+
+func TestMessage_BuildWithLimit(t *testing.T) {
+	a, b := testRRset("a.example.com", 10), testRRset("b.example.com", 10)
+	opt := *DefaultOpt(1232)
+	msg := func(answers, nameservers, additional []Record) *Message {
+		m := testAnswer(testQuery(), "192.0.2.1")
+		m.Answers, m.Nameservers, m.Additional = answers, nameservers, additional
+		return m
+	}
+	concat := func(sets ...[]Record) []Record {
+		var records []Record
+		for _, s := range sets {
+			records = append(records, s...)
+		}
+		return records
+	}
+	// Records of b interleaved with a are still dropped as one RRset
+	interleaved := concat(a[:5], b[:1], a[5:], b[1:])
+
+	tests := []struct {
+		name    string
+		m       *Message
+		limit   *Message
+		want    *Message
+		wantTC  bool
+		wantErr error
+	}{
+		{
+			name:  "Fits",
+			m:     msg(a, nil, nil),
+			limit: msg(a, nil, nil),
+			want:  msg(a, nil, nil),
+		},
+		{
+			name:   "Drop answer RRset",
+			m:      msg(concat(a, b), nil, nil),
+			limit:  msg(concat(a, b[:5]), nil, nil),
+			want:   msg(a, nil, nil),
+			wantTC: true,
+		},
+		{
+			name:   "Drop interleaved RRset",
+			m:      msg(interleaved, nil, nil),
+			limit:  msg(concat(a, b[:5]), nil, nil),
+			want:   msg(a, nil, nil),
+			wantTC: true,
+		},
+		{
+			name:   "Drop authority",
+			m:      msg(a, b, nil),
+			limit:  msg(a, b[:5], nil),
+			want:   msg(a, nil, nil),
+			wantTC: true,
+		},
+		{
+			name:  "Drop additional without TC",
+			m:     msg(a, nil, concat(b, []Record{opt})),
+			limit: msg(a, nil, concat(b[:5], []Record{opt})),
+			want:  msg(a, nil, []Record{opt}),
+		},
+		{
+			name: "Keep TC when dropping additional",
+			m: func() *Message {
+				m := msg(a, nil, b)
+				m.TC = true
+				return m
+			}(),
+			limit:  msg(a, nil, b[:5]),
+			want:   msg(a, nil, nil),
+			wantTC: true,
+		},
+		{
+			name: "Drop signature with its RRset",
+			m: msg(concat(a, []Record{testSig("a.example.com", A)},
+				b, []Record{testSig("b.example.com", A)}), nil, nil),
+			limit:  msg(concat(a, []Record{testSig("a.example.com", A)}, b), nil, nil),
+			want:   msg(concat(a, []Record{testSig("a.example.com", A)}), nil, nil),
+			wantTC: true,
+		},
+		{
+			name:    "Question does not fit",
+			m:       msg(a, nil, nil),
+			limit:   &Message{},
+			wantErr: ErrMessageTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := builtLen(t, tt.limit)
+			wasTC := tt.m.TC
+			buf := new(bytes.Buffer)
+			err := tt.m.BuildWithLimit(buf, NewDomains(), limit)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Message.BuildWithLimit() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Message.BuildWithLimit() error = %v", err)
+			}
+			if buf.Len() > limit {
+				t.Errorf("Message.BuildWithLimit() length = %d, want at most %d", buf.Len(), limit)
+			}
+			got, err := ParseMessage(buf)
+			if err != nil {
+				t.Fatalf("ParseMessage() error = %v", err)
+			}
+			if got.TC != tt.wantTC {
+				t.Errorf("Message.BuildWithLimit() TC = %v, want %v", got.TC, tt.wantTC)
+			}
+			if tt.m.TC != wasTC {
+				t.Error("Message.BuildWithLimit() modified the message")
+			}
+			tt.want.TC = tt.wantTC
+			if gotStr, wantStr := fmt.Sprint(got), fmt.Sprint(tt.want); gotStr != wantStr {
+				t.Errorf("Message.BuildWithLimit() =\n%s\nwant\n%s", gotStr, wantStr)
+			}
+		})
+	}
+}
+
+func TestMessage_BuildWithLimit_Uncompressed(t *testing.T) {
+	// RRsets of one record each, which only fit up to the limit when compressed
+	m := testAnswer(testQuery(), "192.0.2.1")
+	m.Answers = nil
+	for i := 0; i < 40; i++ {
+		m.Answers = append(m.Answers, testRRset(fmt.Sprintf("host%d.example.com", i), 1)...)
+	}
+	buf := new(bytes.Buffer)
+	if err := m.BuildWithLimit(buf, nil, minUDPSize); err != nil {
+		t.Fatalf("Message.BuildWithLimit() error = %v", err)
+	}
+	if buf.Len() > minUDPSize {
+		t.Errorf("Message.BuildWithLimit() length = %d, want at most %d", buf.Len(), minUDPSize)
+	}
+	got, err := ParseMessage(buf)
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if !got.TC || len(got.Answers) == 0 || len(got.Answers) == len(m.Answers) {
+		t.Errorf("Message.BuildWithLimit() TC = %v with %d answers, want TC with some answers",
+			got.TC, len(got.Answers))
+	}
+}
+
+func TestMessage_UDPSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		additional []Record
+		want       int
+	}{
+		{name: "No EDNS", want: 512},
+		{name: "EDNS", additional: []Record{*DefaultOpt(1232)}, want: 1232},
+		{name: "EDNS below minimum", additional: []Record{*DefaultOpt(100)}, want: 512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Message{Additional: tt.additional}
+			if got := m.UDPSize(); got != tt.want {
+				t.Errorf("Message.UDPSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
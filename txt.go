@@ -3,7 +3,9 @@ package dns
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // Txt implements interface RData
@@ -63,3 +65,30 @@ func (t *Txt) PreBuild(_ *Record, _ *Domains) (int, error) {
 func (t *Txt) TransformName(name string) string {
 	return name
 }
+
+// parseText implements presentation format parsing of TXT records
+func (t *Txt) parseText(text *rdataText) error {
+	if !text.more() {
+		return errors.New("missing TXT string")
+	}
+	for text.more() {
+		s, err := text.text("TXT string")
+		if err != nil {
+			return err
+		}
+		if len(s) > 255 {
+			return fmt.Errorf("TXT string too long: %d > 255", len(s))
+		}
+		t.Data = append(t.Data, s)
+	}
+	return nil
+}
+
+// String returns the RDATA in presentation format
+func (t *Txt) String() string {
+	parts := make([]string, len(t.Data))
+	for i, s := range t.Data {
+		parts[i] = quoteText(s)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,43 @@
+package dns
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Unknown implements interface RData for record types without a dedicated
+// implementation. The RDATA is kept as raw bytes as described in RFC 3597.
+type Unknown struct {
+	Data   []byte
+	length uint16
+}
+
+// Parse implements raw RDATA parsing for interface RData
+func (u *Unknown) Parse(buf *bytes.Buffer, _ int, _ *Domains) error {
+	if buf.Len() < int(u.length) {
+		return fmt.Errorf("unable to read unknown RDATA: %d bytes left, want %d",
+			buf.Len(), u.length)
+	}
+	u.Data = make([]byte, u.length)
+	copy(u.Data, buf.Next(int(u.length)))
+	return nil
+}
+
+// Build implements raw RDATA building for interface RData
+func (u *Unknown) Build(buf *bytes.Buffer, _ *Domains) error {
+	buf.Write(u.Data)
+	return nil
+}
+
+// PreBuild step, just returning record size
+func (u *Unknown) PreBuild(_ *Record, _ *Domains) (int, error) {
+	return len(u.Data), nil
+}
+
+// TransformName satisfies the interface
+func (*Unknown) TransformName(name string) string { return name }
+
+// String returns the RDATA in the generic `\# <len> <hex>` presentation form
+func (u *Unknown) String() string {
+	return genericString(u.Data)
+}
@@ -0,0 +1,106 @@
+package dns
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestUnknown_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		length  uint16
+		want    *Unknown
+		wantErr bool
+	}{
+		{
+			name:   "Simple RDATA",
+			buf:    []byte("\x0a\x00\x00\x01\xff"),
+			length: 4,
+			want:   &Unknown{Data: []byte("\x0a\x00\x00\x01"), length: 4},
+		},
+		{
+			name:   "Empty RDATA",
+			buf:    []byte{},
+			length: 0,
+			want:   &Unknown{Data: []byte{}},
+		},
+		{
+			name:    "Not enough data in buffer",
+			buf:     []byte("\x0a\x00"),
+			length:  4,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Unknown{length: tt.length}
+			if err := u.Parse(bytes.NewBuffer(tt.buf), 0, NewDomains()); (err != nil) != tt.wantErr {
+				t.Errorf("Unknown.Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(u, tt.want) {
+				t.Errorf("Unknown.Parse() = %v, want %v", u, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknown_Build(t *testing.T) {
+	tests := []struct {
+		name       string
+		unknown    Unknown
+		want       []byte
+		wantLength int
+	}{
+		{
+			name:       "Simple RDATA",
+			unknown:    Unknown{Data: []byte("\x0a\x00\x00\x01")},
+			want:       []byte("\x0a\x00\x00\x01"),
+			wantLength: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			if length, err := tt.unknown.PreBuild(&Record{}, NewDomains()); err != nil || length != tt.wantLength {
+				t.Errorf("Unknown.PreBuild() = %v, %v, want %v", length, err, tt.wantLength)
+				return
+			}
+			if err := tt.unknown.Build(buf, NewDomains()); err != nil {
+				t.Errorf("Unknown.Build() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(buf.Bytes(), tt.want) {
+				t.Errorf("Unknown.Build() = %v, want %v", buf.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknown_String(t *testing.T) {
+	tests := []struct {
+		name    string
+		unknown Unknown
+		want    string
+	}{
+		{
+			name:    "Simple RDATA",
+			unknown: Unknown{Data: []byte("\x0a\x00\x00\x01")},
+			want:    `\# 4 0a000001`,
+		},
+		{
+			name:    "Empty RDATA",
+			unknown: Unknown{},
+			want:    `\# 0`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.unknown.String(); got != tt.want {
+				t.Errorf("Unknown.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package dns
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Errors returned when validating DNSSEC signatures
+var (
+	ErrNoSignature          = errors.New("dnssec: no signature covering the RRset")
+	ErrNoTrustedKey         = errors.New("dnssec: no trusted key for signature")
+	ErrSignatureExpired     = errors.New("dnssec: signature outside validity period")
+	ErrBogusSignature       = errors.New("dnssec: signature verification failed")
+	ErrUnsupportedAlgorithm = errors.New("dnssec: unsupported algorithm")
+)
+
+// Validator verifies RRsets against a chain of trust starting at a set of
+// trust anchors. Keys and DS records proven along the way are remembered, so
+// a chain is validated by verifying the DNSKEY and DS RRsets from the top
+// down before verifying the RRset of interest.
+type Validator struct {
+	// Now returns the time used to check signature validity periods,
+	// defaults to time.Now
+	Now func() time.Time
+
+	anchors map[string][]*Ds
+	keys    map[string][]*Dnskey
+}
+
+// NewValidator returns a Validator without any trust anchors
+func NewValidator() *Validator {
+	return &Validator{
+		anchors: map[string][]*Ds{},
+		keys:    map[string][]*Dnskey{},
+	}
+}
+
+// AddTrustAnchor adds a DS or DNSKEY record as a trust anchor for the zone
+// named by the record owner
+func (v *Validator) AddTrustAnchor(r Record) error {
+	zone := CanonicalName(r.Name)
+	switch d := r.Data.(type) {
+	case *Ds:
+		v.anchors[zone] = append(v.anchors[zone], d)
+	case *Dnskey:
+		v.keys[zone] = append(v.keys[zone], d)
+	default:
+		return fmt.Errorf("dnssec: trust anchor must be DS or DNSKEY, got %s",
+			RRTypeStrings[r.Type])
+	}
+	return nil
+}
+
+// Verify validates rrset using the RRSIG records in sigs. A verified DNSKEY
+// RRset makes all its zone keys trusted for the zone, and a verified DS RRset
+// is added as trust anchor for the delegated zone.
+func (v *Validator) Verify(rrset []Record, sigs []Record) error {
+	if len(rrset) == 0 {
+		return errors.New("dnssec: empty RRset")
+	}
+	for _, r := range rrset[1:] {
+		if r.Type != rrset[0].Type || r.Class != rrset[0].Class ||
+			CanonicalName(r.Name) != CanonicalName(rrset[0].Name) {
+			return errors.New("dnssec: records do not form an RRset")
+		}
+	}
+	owner := CanonicalName(rrset[0].Name)
+
+	result := ErrNoSignature
+	for _, r := range sigs {
+		sig, ok := r.Data.(*Rrsig)
+		if !ok || sig.TypeCovered != rrset[0].Type ||
+			CanonicalName(r.Name) != owner {
+			continue
+		}
+		signer := CanonicalName(sig.SignerName)
+		if !IsSubdomain(owner, signer) {
+			continue
+		}
+		if !sig.ValidAt(v.now()) {
+			result = moreSevere(result, ErrSignatureExpired)
+			continue
+		}
+		keys := v.keys[signer]
+		if rrset[0].Type == DNSKEY && owner == signer {
+			keys = append(v.anchoredKeys(signer, rrset), keys...)
+		}
+		result = moreSevere(result, ErrNoTrustedKey)
+		for _, key := range keys {
+			if key.KeyTag() != sig.KeyTag || key.Algorithm != sig.Algorithm {
+				continue
+			}
+			err := sig.Verify(key, rrset)
+			if err == nil {
+				v.learn(owner, rrset)
+				return nil
+			}
+			result = moreSevere(result, err)
+		}
+	}
+	return result
+}
+
+// moreSevere returns the most informative of two validation errors
+func moreSevere(current, next error) error {
+	rank := func(err error) int {
+		switch {
+		case errors.Is(err, ErrNoSignature):
+			return 0
+		case errors.Is(err, ErrNoTrustedKey):
+			return 1
+		case errors.Is(err, ErrSignatureExpired):
+			return 2
+		}
+		return 3
+	}
+	if rank(next) > rank(current) {
+		return next
+	}
+	return current
+}
+
+func (v *Validator) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+	return time.Now()
+}
+
+// anchoredKeys returns the keys of a DNSKEY RRset matching a DS anchor
+func (v *Validator) anchoredKeys(zone string, rrset []Record) []*Dnskey {
+	var keys []*Dnskey
+	for _, r := range rrset {
+		key, ok := r.Data.(*Dnskey)
+		if !ok {
+			continue
+		}
+		for _, ds := range v.anchors[zone] {
+			if ds.Matches(zone, key) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// learn adds the records of a verified DNSKEY or DS RRset to the chain of
+// trust
+func (v *Validator) learn(owner string, rrset []Record) {
+	for _, r := range rrset {
+		switch d := r.Data.(type) {
+		case *Dnskey:
+			if d.IsZoneKey() && !d.IsRevoked() && d.Protocol == DnskeyProtocol &&
+				!containsRData(v.keys[owner], d) {
+				v.keys[owner] = append(v.keys[owner], d)
+			}
+		case *Ds:
+			if !containsRData(v.anchors[owner], d) {
+				v.anchors[owner] = append(v.anchors[owner], d)
+			}
+		}
+	}
+}
+
+// containsRData reports whether list holds a record with the same RDATA as
+// data
+func containsRData[T RData](list []T, data T) bool {
+	want, _ := canonicalRData(&Record{Data: data})
+	for _, d := range list {
+		if got, _ := canonicalRData(&Record{Data: d}); bytes.Equal(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidAt reports whether t is within the validity period of the signature,
+// using serial number arithmetic as described in RFC 4034 section 3.1.5
+func (s *Rrsig) ValidAt(t time.Time) bool {
+	now := uint32(t.Unix())
+	return !SerialLess(now, s.Inception) && !SerialLess(s.Expiration, now)
+}
+
+// Verify checks the signature over rrset using key. It does not check the
+// validity period or whether the key is trusted.
+func (s *Rrsig) Verify(key *Dnskey, rrset []Record) error {
+	if len(rrset) == 0 {
+		return errors.New("dnssec: empty RRset")
+	}
+	if s.TypeCovered != rrset[0].Type {
+		return fmt.Errorf("dnssec: signature covers %s, not %s",
+			RRTypeStrings[s.TypeCovered], RRTypeStrings[rrset[0].Type])
+	}
+	if int(s.Labels) > len(nameLabels(rrset[0].Name)) {
+		return fmt.Errorf("dnssec: signature labels %d exceed owner labels", s.Labels)
+	}
+	if key.Protocol != DnskeyProtocol || !key.IsZoneKey() {
+		return errors.New("dnssec: key is not a DNSSEC zone key")
+	}
+	if s.Algorithm != key.Algorithm || s.KeyTag != key.KeyTag() {
+		return errors.New("dnssec: signature does not match key")
+	}
+	data, err := signedData(s, rrset)
+	if err != nil {
+		return err
+	}
+	return verifySignature(key, data, s.Signature)
+}
+
+// verifySignature verifies sig over data with the public key of key
+func verifySignature(key *Dnskey, data, sig []byte) error {
+	pub, err := key.CryptoPublicKey()
+	if err != nil {
+		return err
+	}
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		h, hashed := algorithmHash(key.Algorithm, data)
+		if err := rsa.VerifyPKCS1v15(pub, h, hashed, sig); err != nil {
+			return ErrBogusSignature
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrBogusSignature
+		}
+		_, hashed := algorithmHash(key.Algorithm, data)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, hashed, r, s) {
+			return ErrBogusSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, data, sig) {
+			return ErrBogusSignature
+		}
+	}
+	return nil
+}
+
+// algorithmHash returns the hash function of alg and the digest of data
+func algorithmHash(alg uint8, data []byte) (crypto.Hash, []byte) {
+	switch alg {
+	case RSASHA1, RSASHA1NSEC3SHA1:
+		sum := sha1.Sum(data)
+		return crypto.SHA1, sum[:]
+	case RSASHA512:
+		sum := sha512.Sum512(data)
+		return crypto.SHA512, sum[:]
+	case ECDSAP384SHA384:
+		sum := sha512.Sum384(data)
+		return crypto.SHA384, sum[:]
+	default:
+		sum := sha256.Sum256(data)
+		return crypto.SHA256, sum[:]
+	}
+}
+
+// CryptoPublicKey returns the public key of the DNSKEY as a crypto.PublicKey
+func (k *Dnskey) CryptoPublicKey() (crypto.PublicKey, error) {
+	switch k.Algorithm {
+	case RSASHA1, RSASHA1NSEC3SHA1, RSASHA256, RSASHA512:
+		return parseRSAPublicKey(k.PublicKey)
+	case ECDSAP256SHA256, ECDSAP384SHA384:
+		curve := elliptic.P256()
+		if k.Algorithm == ECDSAP384SHA384 {
+			curve = elliptic.P384()
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(k.PublicKey) != 2*size {
+			return nil, fmt.Errorf("dnssec: invalid ECDSA key length: %d", len(k.PublicKey))
+		}
+		pub := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(k.PublicKey[:size]),
+			Y:     new(big.Int).SetBytes(k.PublicKey[size:]),
+		}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("dnssec: ECDSA key not on curve")
+		}
+		return pub, nil
+	case ED25519:
+		if len(k.PublicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("dnssec: invalid Ed25519 key length: %d", len(k.PublicKey))
+		}
+		return ed25519.PublicKey(k.PublicKey), nil
+	}
+	return nil, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, k.Algorithm)
+}
+
+// parseRSAPublicKey parses the RSA public key format of RFC 3110 section 2
+func parseRSAPublicKey(b []byte) (*rsa.PublicKey, error) {
+	if len(b) < 3 {
+		return nil, errors.New("dnssec: RSA key too short")
+	}
+	expLen, b := int(b[0]), b[1:]
+	if expLen == 0 {
+		if len(b) < 2 {
+			return nil, errors.New("dnssec: RSA key too short")
+		}
+		expLen, b = int(b[0])<<8|int(b[1]), b[2:]
+	}
+	if expLen == 0 || expLen > 4 || len(b) <= expLen {
+		return nil, fmt.Errorf("dnssec: invalid RSA exponent length: %d", expLen)
+	}
+	exp := 0
+	for _, c := range b[:expLen] {
+		exp = exp<<8 | int(c)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(b[expLen:]), E: exp}, nil
+}
@@ -0,0 +1,257 @@
+package dns
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+var testNow = time.Unix(1700000000, 0)
+
+// testKey generates a key pair for alg and returns it as a DNSKEY
+func testKey(t *testing.T, alg uint8, flags uint16) (*Dnskey, crypto.Signer) {
+	t.Helper()
+	key, err := GenerateKey(alg, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key.Key, key.Private
+}
+
+// testSign signs rrset with priv, returning the RRSIG record
+func testSign(t *testing.T, priv crypto.Signer, key *Dnskey, signer string, rrset []Record) Record {
+	t.Helper()
+	sig := &Rrsig{
+		TypeCovered: rrset[0].Type,
+		Algorithm:   key.Algorithm,
+		Labels:      rrsigLabels(rrset[0].Name),
+		OriginalTTL: rrset[0].TTL,
+		Expiration:  uint32(testNow.Add(time.Hour).Unix()),
+		Inception:   uint32(testNow.Add(-time.Hour).Unix()),
+		KeyTag:      key.KeyTag(),
+		SignerName:  signer,
+	}
+	data, err := signedData(sig, rrset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch k := priv.(type) {
+	case *rsa.PrivateKey:
+		h, hashed := algorithmHash(key.Algorithm, data)
+		sig.Signature, err = rsa.SignPKCS1v15(rand.Reader, k, h, hashed)
+	case *ecdsa.PrivateKey:
+		_, hashed := algorithmHash(key.Algorithm, data)
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, hashed)
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig.Signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	case ed25519.PrivateKey:
+		sig.Signature = ed25519.Sign(k, data)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Record{Name: rrset[0].Name, Type: RRSIG, Class: rrset[0].Class, TTL: rrset[0].TTL, Data: sig}
+}
+
+func testARRset(name string, addrs ...string) []Record {
+	var rrset []Record
+	for _, a := range addrs {
+		rrset = append(rrset, Record{
+			Name:  name,
+			Type:  A,
+			Class: uint16(IN),
+			TTL:   300,
+			Data:  &IPv4{netip.MustParseAddr(a)},
+		})
+	}
+	return rrset
+}
+
+func TestRrsig_Verify(t *testing.T) {
+	for _, alg := range []uint8{RSASHA256, ECDSAP256SHA256, ECDSAP384SHA384, ED25519} {
+		t.Run(AlgorithmStrings[alg], func(t *testing.T) {
+			key, priv := testKey(t, alg, DnskeyZoneKey)
+			rrset := testARRset("www.example.com", "192.0.2.1", "192.0.2.2")
+			sig := testSign(t, priv, key, "example.com", rrset).Data.(*Rrsig)
+
+			// Order and case of the RRset must not matter
+			reordered := testARRset("WWW.Example.com", "192.0.2.2", "192.0.2.1")
+			if err := sig.Verify(key, reordered); err != nil {
+				t.Errorf("Rrsig.Verify() error = %v", err)
+			}
+
+			tampered := testARRset("www.example.com", "192.0.2.1", "192.0.2.3")
+			if err := sig.Verify(key, tampered); !errors.Is(err, ErrBogusSignature) {
+				t.Errorf("Rrsig.Verify() tampered error = %v, want %v", err, ErrBogusSignature)
+			}
+		})
+	}
+}
+
+func TestRrsig_VerifyWildcard(t *testing.T) {
+	key, priv := testKey(t, ED25519, DnskeyZoneKey)
+	sig := testSign(t, priv, key, "example.com", testARRset("*.example.com", "192.0.2.1")).Data.(*Rrsig)
+	if sig.Labels != 2 {
+		t.Fatalf("Rrsig.Labels = %d, want 2", sig.Labels)
+	}
+	if err := sig.Verify(key, testARRset("host.example.com", "192.0.2.1")); err != nil {
+		t.Errorf("Rrsig.Verify() wildcard expansion error = %v", err)
+	}
+}
+
+func TestRrsig_ValidAt(t *testing.T) {
+	sig := &Rrsig{
+		Inception:  uint32(testNow.Add(-time.Hour).Unix()),
+		Expiration: uint32(testNow.Add(time.Hour).Unix()),
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "Within period", t: testNow, want: true},
+		{name: "Before inception", t: testNow.Add(-2 * time.Hour), want: false},
+		{name: "After expiration", t: testNow.Add(2 * time.Hour), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sig.ValidAt(tt.t); got != tt.want {
+				t.Errorf("Rrsig.ValidAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidator_Verify(t *testing.T) {
+	// Chain of trust: root KSK (DS anchor) -> root ZSK -> DS for example ->
+	// example KSK -> example ZSK -> A record
+	rootKSK, rootKSKPriv := testKey(t, ECDSAP256SHA256, DnskeyZoneKey|DnskeySEP)
+	rootZSK, rootZSKPriv := testKey(t, ECDSAP256SHA256, DnskeyZoneKey)
+	exKSK, exKSKPriv := testKey(t, ED25519, DnskeyZoneKey|DnskeySEP)
+	exZSK, exZSKPriv := testKey(t, ED25519, DnskeyZoneKey)
+
+	rootKeys := []Record{
+		{Name: "", Type: DNSKEY, Class: uint16(IN), TTL: 3600, Data: rootKSK},
+		{Name: "", Type: DNSKEY, Class: uint16(IN), TTL: 3600, Data: rootZSK},
+	}
+	rootAnchor, _ := NewDs("", rootKSK, DigestSHA256)
+	exDs, _ := NewDs("example", exKSK, DigestSHA256)
+	exDsSet := []Record{{Name: "example", Type: DS, Class: uint16(IN), TTL: 3600, Data: exDs}}
+	exKeys := []Record{
+		{Name: "example", Type: DNSKEY, Class: uint16(IN), TTL: 3600, Data: exKSK},
+		{Name: "example", Type: DNSKEY, Class: uint16(IN), TTL: 3600, Data: exZSK},
+	}
+	answer := testARRset("www.example", "192.0.2.1")
+
+	rootKeysSig := testSign(t, rootKSKPriv, rootKSK, "", rootKeys)
+	exDsSig := testSign(t, rootZSKPriv, rootZSK, "", exDsSet)
+	exKeysSig := testSign(t, exKSKPriv, exKSK, "example", exKeys)
+	answerSig := testSign(t, exZSKPriv, exZSK, "example", answer)
+
+	t.Run("Full chain", func(t *testing.T) {
+		v := NewValidator()
+		v.Now = func() time.Time { return testNow }
+		if err := v.AddTrustAnchor(Record{Name: "", Type: DS, Data: rootAnchor}); err != nil {
+			t.Fatal(err)
+		}
+		steps := []struct {
+			name  string
+			rrset []Record
+			sig   Record
+		}{
+			{name: "root DNSKEY", rrset: rootKeys, sig: rootKeysSig},
+			{name: "example DS", rrset: exDsSet, sig: exDsSig},
+			{name: "example DNSKEY", rrset: exKeys, sig: exKeysSig},
+			{name: "answer", rrset: answer, sig: answerSig},
+		}
+		for _, step := range steps {
+			if err := v.Verify(step.rrset, []Record{step.sig}); err != nil {
+				t.Errorf("Validator.Verify() %s error = %v", step.name, err)
+			}
+		}
+	})
+
+	t.Run("Missing link", func(t *testing.T) {
+		v := NewValidator()
+		v.Now = func() time.Time { return testNow }
+		v.AddTrustAnchor(Record{Name: "", Type: DS, Data: rootAnchor})
+		v.Verify(rootKeys, []Record{rootKeysSig})
+		if err := v.Verify(exKeys, []Record{exKeysSig}); !errors.Is(err, ErrNoTrustedKey) {
+			t.Errorf("Validator.Verify() error = %v, want %v", err, ErrNoTrustedKey)
+		}
+	})
+
+	t.Run("Expired signature", func(t *testing.T) {
+		v := NewValidator()
+		v.Now = func() time.Time { return testNow.Add(24 * time.Hour) }
+		v.AddTrustAnchor(Record{Name: "example", Type: DNSKEY, Data: exZSK})
+		if err := v.Verify(answer, []Record{answerSig}); !errors.Is(err, ErrSignatureExpired) {
+			t.Errorf("Validator.Verify() error = %v, want %v", err, ErrSignatureExpired)
+		}
+	})
+
+	t.Run("Bogus signature", func(t *testing.T) {
+		v := NewValidator()
+		v.Now = func() time.Time { return testNow }
+		v.AddTrustAnchor(Record{Name: "example", Type: DNSKEY, Data: exZSK})
+		forged := testARRset("www.example", "192.0.2.66")
+		if err := v.Verify(forged, []Record{answerSig}); !errors.Is(err, ErrBogusSignature) {
+			t.Errorf("Validator.Verify() error = %v, want %v", err, ErrBogusSignature)
+		}
+	})
+
+	t.Run("No signature", func(t *testing.T) {
+		v := NewValidator()
+		if err := v.Verify(answer, nil); !errors.Is(err, ErrNoSignature) {
+			t.Errorf("Validator.Verify() error = %v, want %v", err, ErrNoSignature)
+		}
+	})
+
+	t.Run("Bad trust anchor", func(t *testing.T) {
+		v := NewValidator()
+		if err := v.AddTrustAnchor(answer[0]); err == nil {
+			t.Errorf("Validator.AddTrustAnchor() error = nil, want error")
+		}
+	})
+}
+
+func TestDnskey_CryptoPublicKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     *Dnskey
+		wantErr bool
+	}{
+		{name: "RSASHA1 key", key: rsaSHA1Key},
+		{name: "Ed25519 key", key: ed25519Key},
+		{
+			name:    "Short Ed25519 key",
+			key:     &Dnskey{Algorithm: ED25519, PublicKey: []byte("\x01\x02")},
+			wantErr: true,
+		},
+		{
+			name:    "ECDSA key not on curve",
+			key:     &Dnskey{Algorithm: ECDSAP256SHA256, PublicKey: make([]byte, 64)},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported algorithm",
+			key:     &Dnskey{Algorithm: ED448, PublicKey: make([]byte, 57)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.key.CryptoPublicKey(); (err != nil) != tt.wantErr {
+				t.Errorf("Dnskey.CryptoPublicKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,744 @@
+package dns
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxIncludeDepth limits nesting of $INCLUDE directives
+const maxIncludeDepth = 8
+
+// ZoneError is returned for syntax errors in zone files
+type ZoneError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ZoneError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ZoneError) Unwrap() error { return e.Err }
+
+// ParseZone reads the records of a zone in the master file format of RFC 1035
+// section 5 from r. Relative names are completed with origin, which is taken
+// relative to the root with or without a trailing dot. $INCLUDE is not allowed
+// as there is no file to resolve the included path against, use ParseZoneFile
+// for zones split over several files.
+func ParseZone(r io.Reader, origin string) ([]Record, error) {
+	p, err := newZoneParser(origin, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := p.parse(r); err != nil {
+		return nil, err
+	}
+	return p.records, nil
+}
+
+// ParseZoneFile reads the records of the zone file at path, see ParseZone.
+// Included files are resolved relative to the directory of the including
+// file.
+func ParseZoneFile(path, origin string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := newZoneParser(origin, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.parse(f); err != nil {
+		return nil, err
+	}
+	return p.records, nil
+}
+
+// newZoneParser returns a parser for the zone file at path, with origin
+// converted to the form of names like $ORIGIN
+func newZoneParser(origin, path string) (*zoneParser, error) {
+	if origin != "" {
+		o, err := zoneName(origin, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid origin %q: %w", origin, err)
+		}
+		origin = o
+	}
+	return &zoneParser{origin: origin, class: IN, file: path}, nil
+}
+
+// ParseRecordString parses a single record in presentation format, as
+// returned by Record.String. The owner name and TTL must be given, and
+// relative names are taken relative to the root.
+func ParseRecordString(s string) (Record, error) {
+	records, err := ParseZone(strings.NewReader(s), "")
+	if err != nil {
+		return Record{}, err
+	}
+	if len(records) != 1 {
+		return Record{}, fmt.Errorf("expected one record, got %d", len(records))
+	}
+	return records[0], nil
+}
+
+// zoneParser holds the state carried between the entries of a zone file
+type zoneParser struct {
+	file     string
+	depth    int
+	origin   string
+	owner    string
+	hasOwner bool
+	class    Class
+	ttl      uint32
+	hasTTL   bool
+	lastTTL  uint32
+	hasLast  bool
+	records  []Record
+}
+
+func (p *zoneParser) parse(r io.Reader) error {
+	lex := &zoneLexer{r: bufio.NewReader(r), line: 1}
+	for {
+		entry, err := lex.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &ZoneError{File: p.file, Line: lex.line, Err: err}
+		}
+		if err := p.parseEntry(entry); err != nil {
+			var zerr *ZoneError
+			if errors.As(err, &zerr) {
+				return err
+			}
+			return &ZoneError{File: p.file, Line: entry.line, Err: err}
+		}
+	}
+}
+
+func (p *zoneParser) parseEntry(entry zoneEntry) error {
+	tokens := entry.tokens
+	if !entry.blankOwner && strings.HasPrefix(tokens[0].text, "$") {
+		return p.parseDirective(tokens)
+	}
+
+	r := Record{}
+	if entry.blankOwner {
+		if !p.hasOwner {
+			return errors.New("record without owner name")
+		}
+		r.Name = p.owner
+	} else {
+		name, err := zoneName(tokens[0].text, p.origin)
+		if err != nil {
+			return err
+		}
+		r.Name = name
+		tokens = tokens[1:]
+	}
+	p.owner, p.hasOwner = r.Name, true
+
+	// TTL and class may appear in either order before the type
+	var ttl uint32
+	hasTTL, hasClass := false, false
+	for len(tokens) > 0 {
+		if class, ok := parseClass(tokens[0].text); ok && !hasClass {
+			p.class = class
+			hasClass = true
+		} else if v, err := parseTTL(tokens[0].text); err == nil && !hasTTL {
+			ttl = v
+			hasTTL = true
+		} else {
+			break
+		}
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return errors.New("missing record type")
+	}
+	t, err := parseType(tokens[0].text)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case hasTTL:
+		p.lastTTL, p.hasLast = ttl, true
+	case p.hasTTL:
+		ttl = p.ttl
+	case p.hasLast:
+		ttl = p.lastTTL
+	default:
+		return errors.New("no TTL given and no $TTL directive")
+	}
+	r.Type = t
+	r.Class = uint16(p.class)
+	r.TTL = ttl
+	if err := r.parseText(&rdataText{tokens: tokens[1:], origin: p.origin}); err != nil {
+		return fmt.Errorf("invalid %s record: %w", t, err)
+	}
+	p.records = append(p.records, r)
+	return nil
+}
+
+func (p *zoneParser) parseDirective(tokens []zoneToken) error {
+	switch strings.ToUpper(tokens[0].text) {
+	case "$ORIGIN":
+		if len(tokens) != 2 {
+			return errors.New("$ORIGIN takes one name")
+		}
+		origin, err := zoneName(tokens[1].text, p.origin)
+		if err != nil {
+			return err
+		}
+		p.origin = origin
+	case "$TTL":
+		if len(tokens) != 2 {
+			return errors.New("$TTL takes one TTL")
+		}
+		ttl, err := parseTTL(tokens[1].text)
+		if err != nil {
+			return err
+		}
+		p.ttl, p.hasTTL = ttl, true
+	case "$INCLUDE":
+		if len(tokens) < 2 || len(tokens) > 3 {
+			return errors.New("$INCLUDE takes a file name and an optional origin")
+		}
+		return p.include(tokens[1:])
+	default:
+		return fmt.Errorf("unsupported directive %s", tokens[0].text)
+	}
+	return nil
+}
+
+// include parses an included file. The origin and owner of the including
+// file are restored afterwards, as described in RFC 1035 section 5.1.
+func (p *zoneParser) include(args []zoneToken) error {
+	if p.file == "" {
+		return errors.New("$INCLUDE is only supported when parsing zone files")
+	}
+	if p.depth >= maxIncludeDepth {
+		return errors.New("$INCLUDE nested too deep")
+	}
+	path, err := unescapeText(args[0].text)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(p.file), path)
+	}
+	sub := *p
+	sub.file = path
+	sub.depth++
+	sub.records = nil
+	if len(args) == 2 {
+		if sub.origin, err = zoneName(args[1].text, p.origin); err != nil {
+			return err
+		}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := sub.parse(f); err != nil {
+		return err
+	}
+	p.records = append(p.records, sub.records...)
+	p.ttl, p.hasTTL = sub.ttl, sub.hasTTL
+	p.lastTTL, p.hasLast = sub.lastTTL, sub.hasLast
+	return nil
+}
+
+// parseText parses the presentation format RDATA of the record, including the
+// generic \# form of RFC 3597 section 5
+func (r *Record) parseText(t *rdataText) error {
+	if len(t.tokens) > 0 && t.tokens[0].text == `\#` && !t.tokens[0].quoted {
+		t.tokens = t.tokens[1:]
+		length, err := t.uint16("RDATA length")
+		if err != nil {
+			return err
+		}
+		data, err := hex.DecodeString(t.rest())
+		if err != nil {
+			return fmt.Errorf("invalid RDATA hex: %w", err)
+		}
+		if len(data) != int(length) {
+			return fmt.Errorf("RDATA length %d does not match %d bytes of data", length, len(data))
+		}
+		r.Length = length
+		// Names in generic RDATA must not be compressed, as there is no
+		// message for pointers to point into
+		buf := bytes.NewBuffer(data)
+		if err := r.parseRData(buf, 0, NewDomains()); err != nil {
+			return err
+		}
+		if buf.Len() != 0 {
+			return fmt.Errorf("%d bytes of trailing RDATA", buf.Len())
+		}
+		return nil
+	}
+
+	rdata := r.newRData()
+	p, ok := rdata.(textParser)
+	if !ok {
+		return fmt.Errorf(`no presentation format for %s, use the \# form`, r.Type)
+	}
+	if err := p.parseText(t); err != nil {
+		return err
+	}
+	if err := t.end(); err != nil {
+		return err
+	}
+	r.Data = rdata
+	return nil
+}
+
+// textParser is implemented by RData types with a presentation format
+type textParser interface {
+	parseText(*rdataText) error
+}
+
+// rdataText holds the fields of presentation format RDATA
+type rdataText struct {
+	tokens []zoneToken
+	origin string
+}
+
+// next returns the raw text of the next field
+func (t *rdataText) next(what string) (string, error) {
+	if len(t.tokens) == 0 {
+		return "", fmt.Errorf("missing %s", what)
+	}
+	tok := t.tokens[0]
+	t.tokens = t.tokens[1:]
+	return tok.text, nil
+}
+
+// more reports whether there are fields left
+func (t *rdataText) more() bool { return len(t.tokens) > 0 }
+
+// end returns an error if there are fields left
+func (t *rdataText) end() error {
+	if len(t.tokens) > 0 {
+		return fmt.Errorf("unexpected field %q", t.tokens[0].text)
+	}
+	return nil
+}
+
+// rest returns the remaining fields joined together, as used for base64 and
+// hex data split over several fields
+func (t *rdataText) rest() string {
+	var b strings.Builder
+	for _, tok := range t.tokens {
+		b.WriteString(tok.text)
+	}
+	t.tokens = nil
+	return b.String()
+}
+
+// text returns the next field as a character string
+func (t *rdataText) text(what string) (string, error) {
+	s, err := t.next(what)
+	if err != nil {
+		return "", err
+	}
+	return unescapeText(s)
+}
+
+// name returns the next field as a domain name relative to the origin
+func (t *rdataText) name(what string) (string, error) {
+	s, err := t.next(what)
+	if err != nil {
+		return "", err
+	}
+	return zoneName(s, t.origin)
+}
+
+func (t *rdataText) uint(what string, bits int) (uint64, error) {
+	s, err := t.next(what)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", what, s)
+	}
+	return v, nil
+}
+
+func (t *rdataText) uint8(what string) (uint8, error) {
+	v, err := t.uint(what, 8)
+	return uint8(v), err
+}
+
+func (t *rdataText) uint16(what string) (uint16, error) {
+	v, err := t.uint(what, 16)
+	return uint16(v), err
+}
+
+func (t *rdataText) uint32(what string) (uint32, error) {
+	v, err := t.uint(what, 32)
+	return uint32(v), err
+}
+
+// ttl returns the next field as a TTL, allowing unit suffixes
+func (t *rdataText) ttl(what string) (uint32, error) {
+	s, err := t.next(what)
+	if err != nil {
+		return 0, err
+	}
+	return parseTTL(s)
+}
+
+// algorithm returns the next field as a DNSSEC algorithm number or mnemonic
+func (t *rdataText) algorithm() (uint8, error) {
+	s, err := t.next("algorithm")
+	if err != nil {
+		return 0, err
+	}
+	if v, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return uint8(v), nil
+	}
+	for alg, name := range AlgorithmStrings {
+		if strings.EqualFold(name, s) {
+			return alg, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown algorithm %q", s)
+}
+
+// base64 returns the remaining fields decoded as base64
+func (t *rdataText) base64(what string) ([]byte, error) {
+	if !t.more() {
+		return nil, fmt.Errorf("missing %s", what)
+	}
+	b, err := base64.StdEncoding.DecodeString(t.rest())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", what, err)
+	}
+	return b, nil
+}
+
+// hex returns the remaining fields decoded as hex
+func (t *rdataText) hex(what string) ([]byte, error) {
+	if !t.more() {
+		return nil, fmt.Errorf("missing %s", what)
+	}
+	b, err := hex.DecodeString(t.rest())
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", what, err)
+	}
+	return b, nil
+}
+
+// types returns the remaining fields as a list of types
+func (t *rdataText) types() ([]Type, error) {
+	var types []Type
+	for t.more() {
+		s, _ := t.next("type")
+		typ, err := parseType(s)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, typ)
+	}
+	return types, nil
+}
+
+// parseType parses a type mnemonic or the generic TYPEnnn form
+func parseType(s string) (Type, error) {
+	for t, name := range RRTypeStrings {
+		if strings.EqualFold(name, s) {
+			return t, nil
+		}
+	}
+	if len(s) > 4 && strings.EqualFold(s[:4], "TYPE") {
+		if v, err := strconv.ParseUint(s[4:], 10, 16); err == nil {
+			return Type(v), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown type %q", s)
+}
+
+// parseClass parses a class mnemonic or the generic CLASSnnn form
+func parseClass(s string) (Class, bool) {
+	for c, name := range ClassStrings {
+		if strings.EqualFold(name, s) {
+			return c, true
+		}
+	}
+	if len(s) > 5 && strings.EqualFold(s[:5], "CLASS") {
+		if v, err := strconv.ParseUint(s[5:], 10, 16); err == nil {
+			return Class(v), true
+		}
+	}
+	return 0, false
+}
+
+// ttlUnits holds the seconds of each TTL unit suffix
+var ttlUnits = map[rune]uint64{'w': 604800, 'd': 86400, 'h': 3600, 'm': 60, 's': 1}
+
+// parseTTL parses a TTL in seconds, or with the w, d, h, m and s unit
+// suffixes as in 1h30m
+func parseTTL(s string) (uint32, error) {
+	if v, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return uint32(v), nil
+	}
+	var total, n uint64
+	digits := false
+	for _, c := range strings.ToLower(s) {
+		if c >= '0' && c <= '9' {
+			n = n*10 + uint64(c-'0')
+			digits = true
+			if n > 1<<32 {
+				return 0, fmt.Errorf("TTL out of range: %s", s)
+			}
+			continue
+		}
+		unit, ok := ttlUnits[c]
+		if !ok || !digits {
+			return 0, fmt.Errorf("invalid TTL %q", s)
+		}
+		total += n * unit
+		n, digits = 0, false
+	}
+	if digits || total > 1<<32-1 || s == "" {
+		return 0, fmt.Errorf("invalid TTL %q", s)
+	}
+	return uint32(total), nil
+}
+
+// parseSigTime parses an RRSIG time in YYYYMMDDHHmmSS form or as seconds
+// since the epoch, as described in RFC 4034 section 3.2
+func parseSigTime(s string) (uint32, error) {
+	if len(s) == 14 {
+		t, err := time.Parse("20060102150405", s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid signature time %q", s)
+		}
+		// Times past 2106 wrap around using serial number arithmetic
+		return uint32(t.Unix()), nil
+	}
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid signature time %q", s)
+	}
+	return uint32(v), nil
+}
+
+// zoneName completes a presentation format name with origin. "@" is the
+// origin itself, and names not ending in a dot are relative to the origin.
+func zoneName(s, origin string) (string, error) {
+	if s == "@" {
+		return origin, nil
+	}
+	if s == "." {
+		return "", nil
+	}
+	absolute := strings.HasSuffix(s, ".") && !strings.HasSuffix(s, `\.`)
+	if absolute {
+		s = s[:len(s)-1]
+	}
+	labels := splitEscaped(s, '.')
+	for i, label := range labels {
+		l, err := unescapeText(label)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case l == "":
+			return "", fmt.Errorf("empty label in name %q", s)
+		case len(l) > maxLabelLength:
+			return "", fmt.Errorf("label too long in name %q", s)
+		}
+		labels[i] = escapeLabel([]byte(l))
+	}
+	name := strings.Join(labels, ".")
+	if !absolute && origin != "" {
+		name += "." + origin
+	}
+	return name, nil
+}
+
+// splitEscaped splits s at each sep not escaped by a backslash, keeping the
+// escapes in the parts
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescapeText resolves the \X and \DDD escapes of a character string
+func unescapeText(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("trailing backslash in %q", s)
+		}
+		if !isDigit(s[i]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) || !isDigit(s[i+1]) || !isDigit(s[i+2]) {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		v := int(s[i]-'0')*100 + int(s[i+1]-'0')*10 + int(s[i+2]-'0')
+		if v > 255 {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		b.WriteByte(byte(v))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// zoneToken is a field of a zone file entry with quotes removed and escapes
+// kept
+type zoneToken struct {
+	text   string
+	quoted bool
+}
+
+// zoneEntry is a logical line of a zone file
+type zoneEntry struct {
+	tokens     []zoneToken
+	blankOwner bool
+	line       int
+}
+
+// zoneLexer splits a zone file into entries, joining lines within parentheses
+// and dropping comments
+type zoneLexer struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (l *zoneLexer) next() (zoneEntry, error) {
+	entry := zoneEntry{line: l.line}
+	var tok strings.Builder
+	inToken, quoted, inQuote := false, false, false
+	parens := 0
+	start := true
+	flush := func() {
+		if inToken {
+			entry.tokens = append(entry.tokens, zoneToken{text: tok.String(), quoted: quoted})
+		}
+		tok.Reset()
+		inToken, quoted = false, false
+	}
+	for {
+		c, err := l.r.ReadByte()
+		if err == io.EOF {
+			switch {
+			case inQuote:
+				return entry, errors.New("unterminated quoted string")
+			case parens > 0:
+				return entry, errors.New("unbalanced parentheses")
+			}
+			flush()
+			if len(entry.tokens) == 0 {
+				return entry, io.EOF
+			}
+			return entry, nil
+		}
+		if err != nil {
+			return entry, err
+		}
+		if start {
+			start = false
+			entry.blankOwner = c == ' ' || c == '\t'
+		}
+
+		switch {
+		case inQuote && c == '"':
+			inQuote = false
+		case c == '\\':
+			next, err := l.r.ReadByte()
+			if err != nil {
+				return entry, errors.New("trailing backslash")
+			}
+			if next == '\n' {
+				l.line++
+			}
+			tok.WriteByte(c)
+			tok.WriteByte(next)
+			inToken = true
+		case inQuote:
+			if c == '\n' {
+				l.line++
+			}
+			tok.WriteByte(c)
+		case c == '"':
+			inToken, quoted, inQuote = true, true, true
+		case c == ';':
+			for c != '\n' {
+				if c, err = l.r.ReadByte(); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				l.r.UnreadByte()
+			}
+		case c == '(':
+			flush()
+			parens++
+		case c == ')':
+			flush()
+			if parens--; parens < 0 {
+				return entry, errors.New("unbalanced parentheses")
+			}
+		case c == '\n':
+			l.line++
+			flush()
+			if parens > 0 {
+				continue
+			}
+			if len(entry.tokens) > 0 {
+				return entry, nil
+			}
+			entry = zoneEntry{line: l.line}
+			start = true
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		default:
+			tok.WriteByte(c)
+			inToken = true
+		}
+	}
+}
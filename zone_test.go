@@ -0,0 +1,295 @@
+package dns
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testZoneFile = `$ORIGIN example.com.
+$TTL 1h
+; The apex
+@	IN	SOA	ns1 hostmaster (
+		2024010101 ; serial
+		2h 1h 2w 5m )
+	IN	NS	ns1
+	IN	MX	10 mail.example.net.
+ns1	A	192.0.2.53
+www 300 IN A 192.0.2.1
+	IN 600 AAAA	2001:db8::1
+txt	TXT	"hello world" "semi;colon" unquoted \"esc\" "\065\\"
+_sip._tcp	SRV	10 20 5060 sip
+alias	CNAME	www
+svc	HTTPS	1 . port=8443 alpn="h2,h3" ipv4hint=192.0.2.1,192.0.2.2
+$ORIGIN sub
+host	A	192.0.2.9
+`
+
+func TestParseZone(t *testing.T) {
+	rr := func(name string, ttl uint32, typ Type, data RData) Record {
+		return Record{Name: name, Type: typ, Class: uint16(IN), TTL: ttl, Data: data}
+	}
+	want := []Record{
+		rr("example.com", 3600, SOA, &Soa{MName: "ns1.example.com",
+			RName: "hostmaster.example.com", Serial: 2024010101, Refresh: 7200,
+			Retry: 3600, Expire: 1209600, Minimum: 300}),
+		rr("example.com", 3600, NS, &Ns{Name: "ns1.example.com"}),
+		rr("example.com", 3600, MX, &Mx{Preference: 10, Exchange: "mail.example.net"}),
+		rr("ns1.example.com", 3600, A, &IPv4{netip.MustParseAddr("192.0.2.53")}),
+		rr("www.example.com", 300, A, &IPv4{netip.MustParseAddr("192.0.2.1")}),
+		rr("www.example.com", 600, AAAA, &IPv6{netip.MustParseAddr("2001:db8::1")}),
+		rr("txt.example.com", 3600, TXT, &Txt{Data: []string{
+			"hello world", "semi;colon", "unquoted", `"esc"`, `A\`,
+		}}),
+		rr("_sip._tcp.example.com", 3600, SRV, &Srv{Priority: 10, Weight: 20,
+			Port: 5060, Target: "sip.example.com", NameBytes: "_sip._tcp.example.com",
+			Identifier: "_sip", Service: "_tcp", Proto: "example", Name: "com"}),
+		rr("alias.example.com", 3600, CNAME, &CName{Name: "www.example.com"}),
+		rr("svc.example.com", 3600, HTTPS, &Svcb{Priority: 1, Params: []SvcParam{
+			&SvcAlpn{IDs: []string{"h2", "h3"}},
+			&SvcPort{Port: 8443},
+			&SvcIPv4Hint{Addrs: []netip.Addr{
+				netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"),
+			}},
+		}}),
+		rr("host.sub.example.com", 3600, A, &IPv4{netip.MustParseAddr("192.0.2.9")}),
+	}
+
+	got, err := ParseZone(strings.NewReader(testZoneFile), "")
+	if err != nil {
+		t.Fatalf("ParseZone() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseZone() returned %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("ParseZone() record %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseZone_Types(t *testing.T) {
+	expiration := uint32(time.Date(2003, 3, 22, 17, 31, 3, 0, time.UTC).Unix())
+	inception := uint32(time.Date(2003, 2, 20, 17, 31, 3, 0, time.UTC).Unix())
+	nextHashed, _ := nsec3Encoding.DecodeString("2T7B4G4VSA5SMI47K61MV5BV1A22BOJR")
+	tests := []struct {
+		name string
+		text string
+		want RData
+	}{
+		{
+			name: "DNSKEY",
+			text: "@ 3600 IN DNSKEY 257 3 15 ( l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4= )",
+			want: &Dnskey{Flags: 257, Protocol: 3, Algorithm: ED25519,
+				PublicKey: mustDecodeBase64("l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4=")},
+		},
+		{
+			name: "DNSKEY algorithm mnemonic",
+			text: "@ 3600 IN DNSKEY 256 3 ED25519 AQID",
+			want: &Dnskey{Flags: 256, Protocol: 3, Algorithm: ED25519, PublicKey: []byte{1, 2, 3}},
+		},
+		{
+			name: "DS",
+			text: "@ 3600 IN DS 3613 15 2 ( 3aa5ab37efce57f737fc1627013fee07\n" +
+				"bdf241bd10f3b1964ab55c78e79a304b )",
+			want: &Ds{KeyTag: 3613, Algorithm: ED25519, DigestType: DigestSHA256,
+				Digest: mustDecodeHex("3aa5ab37efce57f737fc1627013fee07bdf241bd10f3b1964ab55c78e79a304b")},
+		},
+		{
+			name: "RRSIG",
+			text: "host 86400 IN RRSIG A 5 3 86400 20030322173103 (\n" +
+				"20030220173103 2642 example.com.\n AQID BAU= )",
+			want: &Rrsig{TypeCovered: A, Algorithm: RSASHA1, Labels: 3, OriginalTTL: 86400,
+				Expiration: expiration, Inception: inception, KeyTag: 2642,
+				SignerName: "example.com", Signature: []byte{1, 2, 3, 4, 5}},
+		},
+		{
+			name: "NSEC",
+			text: "alfa 86400 IN NSEC host.example.com. ( A MX RRSIG NSEC TYPE1234 )",
+			want: &Nsec{NextDomain: "host.example.com",
+				TypeBitMap: []Type{A, MX, RRSIG, NSEC, 1234}},
+		},
+		{
+			name: "NSEC3",
+			text: "0p9mhaveqvm6t7vbl5lop2u3t2rp3tom 3600 NSEC3 1 1 12 aabbccdd (\n" +
+				"2t7b4g4vsa5smi47k61mv5bv1a22bojr MX DNSKEY NS SOA NSEC3PARAM RRSIG )",
+			want: &Nsec3{HashAlgorithm: Nsec3SHA1, Flags: Nsec3OptOut, Iterations: 12,
+				Salt:       []byte{0xaa, 0xbb, 0xcc, 0xdd},
+				NextHashed: nextHashed,
+				TypeBitMap: []Type{MX, DNSKEY, NS, SOA, NSEC3PARAM, RRSIG}},
+		},
+		{
+			name: "NSEC3PARAM without salt",
+			text: "@ 0 NSEC3PARAM 1 0 0 -",
+			want: &Nsec3Param{HashAlgorithm: Nsec3SHA1},
+		},
+		{
+			name: "SVCB mandatory and generic key",
+			text: `@ 300 SVCB 16 foo.example.org. (` +
+				` alpn="f\\\\oo\\,bar,h2" mandatory=alpn,ipv4hint ipv4hint=192.0.2.1 key667="hello\210qoo" )`,
+			want: &Svcb{Priority: 16, Target: "foo.example.org", Params: []SvcParam{
+				&SvcMandatory{Keys: []SvcParamKey{SvcParamAlpn, SvcParamIPv4Hint}},
+				&SvcAlpn{IDs: []string{`f\oo,bar`, "h2"}},
+				&SvcIPv4Hint{Addrs: []netip.Addr{netip.MustParseAddr("192.0.2.1")}},
+				&SvcOpaque{KeyCode: 667, Value: []byte("hello\xd2qoo")},
+			}},
+		},
+		{
+			name: "Generic RDATA of known type",
+			text: `@ 300 A \# 4 c0000201`,
+			want: &IPv4{netip.MustParseAddr("192.0.2.1")},
+		},
+		{
+			name: "Generic RDATA of unknown type",
+			text: `@ 300 TYPE731 \# 6 abcd ( ef 012345 )`,
+			want: &Unknown{Data: []byte{0xab, 0xcd, 0xef, 0x01, 0x23, 0x45}, length: 6},
+		},
+		{
+			name: "Empty generic RDATA",
+			text: `@ 300 TYPE731 \# 0`,
+			want: &Unknown{Data: []byte{}, length: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseZone(strings.NewReader(tt.text), "example.com")
+			if err != nil {
+				t.Fatalf("ParseZone() error = %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("ParseZone() returned %d records, want 1", len(got))
+			}
+			if !reflect.DeepEqual(got[0].Data, tt.want) {
+				t.Errorf("ParseZone() = %+v, want %+v", got[0].Data, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseZone_Origin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origin  string
+		want    string
+		wantErr bool
+	}{
+		{name: "Relative", origin: "example.com", want: "www.example.com"},
+		{name: "Absolute", origin: "example.com.", want: "www.example.com"},
+		{name: "Escaped dot", origin: `a\.b.example.`, want: `www.a\.b.example`},
+		{name: "Escaped trailing dot", origin: `example\.`, want: `www.example\.`},
+		{name: "Root", origin: ".", want: "www"},
+		{name: "Empty label", origin: "example..com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseZone(strings.NewReader("www 300 IN A 192.0.2.1"), tt.origin)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseZone() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got[0].Name != tt.want {
+				t.Errorf("ParseZone() name = %q, want %q", got[0].Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseZone_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantLine int
+	}{
+		{name: "No TTL", text: "www IN A 192.0.2.1", wantLine: 1},
+		{name: "No owner", text: "$TTL 300\n  A 192.0.2.1", wantLine: 2},
+		{name: "Unknown type", text: "$TTL 300\n\nwww FOO 1", wantLine: 3},
+		{name: "Bad address", text: "$TTL 300\nwww A 2001:db8::1", wantLine: 2},
+		{name: "Trailing field", text: "$TTL 300\nwww A 192.0.2.1 192.0.2.2", wantLine: 2},
+		{name: "Missing field", text: "$TTL 300\n@ MX 10", wantLine: 2},
+		{name: "Unbalanced parentheses", text: "$TTL 300\n@ MX ( 10\n mail", wantLine: 3},
+		{name: "Unterminated quote", text: "$TTL 300\n@ TXT \"abc", wantLine: 2},
+		{name: "Include without file", text: "$INCLUDE other.zone", wantLine: 1},
+		{name: "Unknown directive", text: "$GENERATE 1-10 host$ A 192.0.2.$", wantLine: 1},
+		{name: "Generic length mismatch", text: `@ 300 TYPE731 \# 3 abcd`, wantLine: 1},
+		{name: "No presentation format", text: "@ 300 HINFO cpu os", wantLine: 1},
+		{name: "Invalid SVCB params", text: "@ 300 SVCB 0 . alpn=h2", wantLine: 1},
+		{name: "Bad escape", text: `@ 300 TXT "\999"`, wantLine: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseZone(strings.NewReader(tt.text), "example.com")
+			var zerr *ZoneError
+			if !errors.As(err, &zerr) {
+				t.Fatalf("ParseZone() error = %v, want ZoneError", err)
+			}
+			if zerr.Line != tt.wantLine {
+				t.Errorf("ZoneError.Line = %d, want %d (%v)", zerr.Line, tt.wantLine, err)
+			}
+		})
+	}
+}
+
+func TestParseZoneFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"example.zone": "$TTL 300\n@ NS ns\n$INCLUDE hosts/a.zone sub\nafter A 192.0.2.3\n",
+		"hosts/a.zone": "host A 192.0.2.1\n     A 192.0.2.2\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := ParseZoneFile(filepath.Join(dir, "example.zone"), "example.com")
+	if err != nil {
+		t.Fatalf("ParseZoneFile() error = %v", err)
+	}
+	var names []string
+	for _, r := range got {
+		names = append(names, r.Name)
+	}
+	want := []string{"example.com", "host.sub.example.com", "host.sub.example.com", "after.example.com"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ParseZoneFile() names = %v, want %v", names, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, "loop.zone"), []byte("$INCLUDE loop.zone\n"), 0o644)
+	if _, err := ParseZoneFile(filepath.Join(dir, "loop.zone"), "example.com"); err == nil {
+		t.Errorf("ParseZoneFile() error = nil, want error for include loop")
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint32
+		wantErr bool
+	}{
+		{in: "3600", want: 3600},
+		{in: "1h30m", want: 5400},
+		{in: "1W2D", want: 777600},
+		{in: "4294967295", want: 4294967295},
+		{in: "4294967296", wantErr: true},
+		{in: "h", wantErr: true},
+		{in: "10x", wantErr: true},
+		{in: "1h5", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTTL(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseTTL(%q) = %d, %v, want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}